@@ -0,0 +1,424 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull raises when entry can not queue because queue of sink is full
+var ErrQueueFull = errors.New("log: queue of sink is full")
+
+// batcher groups queued entries by size, bytes and wait duration and flushes them in background,
+// flush returns error when entries are failed so sync and close report it
+type batcher struct {
+	size    int
+	bytes   int
+	sizer   func(Entry) int
+	wait    time.Duration
+	flush   func([]Entry) error
+	entries chan Entry
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	err     error
+}
+
+func newBatcher(size int, wait time.Duration, capacity int, flush func([]Entry) error) *batcher {
+	return newSizedBatcher(size, 0, nil, wait, capacity, flush)
+}
+
+// newSizedBatcher returns batcher which flushes batches when bytes of sizer reach bytes too, zero bytes disables limit
+func newSizedBatcher(size, bytes int, sizer func(Entry) int, wait time.Duration, capacity int, flush func([]Entry) error) *batcher {
+	if size < 1 {
+		size = 1
+	}
+	if capacity < size {
+		capacity = size
+	}
+	if wait <= 0 {
+		wait = time.Second
+	}
+	b := &batcher{
+		size:    size,
+		bytes:   bytes,
+		sizer:   sizer,
+		wait:    wait,
+		flush:   flush,
+		entries: make(chan Entry, capacity),
+		flushes: make(chan chan error),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues entry without blocking
+func (b *batcher) add(entry Entry) error {
+	select {
+	case <-b.done:
+		return ErrQueueFull
+	default:
+	}
+	select {
+	case b.entries <- entry:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// sync flushes queued entries and waits for flushing, it returns last error of flushes since previous sync
+func (b *batcher) sync() error {
+	ack := make(chan error, 1)
+	select {
+	case b.flushes <- ack:
+		return <-ack
+	case <-b.stopped:
+		return nil
+	}
+}
+
+// close flushes queued entries and stops batcher, it returns last error of failed entries since previous sync
+func (b *batcher) close() error {
+	b.once.Do(func() {
+		close(b.done)
+	})
+	<-b.stopped
+	return b.err
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.wait)
+	defer ticker.Stop()
+	var batch []Entry
+	var bytes int
+	var err error
+	send := func() {
+		if len(batch) > 0 {
+			if e := b.flush(batch); e != nil {
+				err = e
+			}
+			batch = nil
+			bytes = 0
+		}
+	}
+	add := func(entry Entry) {
+		batch = append(batch, entry)
+		if b.bytes > 0 {
+			bytes += b.sizer(entry)
+		}
+		if len(batch) >= b.size || (b.bytes > 0 && bytes >= b.bytes) {
+			send()
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case entry := <-b.entries:
+				add(entry)
+			default:
+				send()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case entry := <-b.entries:
+			add(entry)
+		case <-ticker.C:
+			send()
+		case ack := <-b.flushes:
+			drain()
+			ack <- err
+			err = nil
+		case <-b.done:
+			drain()
+			b.err = err
+			return
+		}
+	}
+}
+
+// backoff returns exponential delay of attempt from min up to max with jitter of half of delay
+func backoff(attempt int, min, max time.Duration) time.Duration {
+	delay := min
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// BatchOption type of batch sink and batch writer option
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	count    int
+	bytes    int
+	wait     time.Duration
+	capacity int
+}
+
+// BatchCount sets maximum count of entries or writes of batches (default: 100)
+func BatchCount(count int) BatchOption {
+	return func(c *batchConfig) {
+		c.count = count
+	}
+}
+
+// BatchBytes sets maximum bytes of batches, zero disables limit (default: 1MiB)
+func BatchBytes(bytes int) BatchOption {
+	return func(c *batchConfig) {
+		c.bytes = bytes
+	}
+}
+
+// BatchWait sets maximum wait duration of batches (default: 1s)
+func BatchWait(wait time.Duration) BatchOption {
+	return func(c *batchConfig) {
+		c.wait = wait
+	}
+}
+
+// BatchBuffer sets capacity of queued entries of batch sink, entries are dropped when queue is full (default: 10000)
+func BatchBuffer(capacity int) BatchOption {
+	return func(c *batchConfig) {
+		c.capacity = capacity
+	}
+}
+
+func newBatchConfig(options []BatchOption) batchConfig {
+	c := batchConfig{count: 100, bytes: 1 << 20, wait: time.Second, capacity: 10000}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// BatchStats keeps counters of batch sink and batch writer
+type BatchStats struct {
+	// Batches keeps count of flushed batches
+	Batches uint64
+
+	// Entries keeps count of flushed entries or writes
+	Entries uint64
+
+	// Bytes keeps count of flushed bytes
+	Bytes uint64
+
+	// Dropped keeps count of entries which are not queued because queue is full
+	Dropped uint64
+
+	// Failed keeps count of entries or writes which are failed on flush
+	Failed uint64
+}
+
+// BatchSender interface of sink which sends batch of entries at once
+type BatchSender interface {
+	// SendBatch sends batch of entries
+	SendBatch([]Entry) error
+}
+
+// BatchSink implements sink which groups entries by count, bytes and wait duration in background
+// and sends them on sink, sinks which implement BatchSender receive batches at once
+type BatchSink struct {
+	sink    Sink
+	batcher *batcher
+	stats   BatchStats
+}
+
+// NewBatchSink returns new batch sink in front of sink
+func NewBatchSink(sink Sink, options ...BatchOption) *BatchSink {
+	c := newBatchConfig(options)
+	s := &BatchSink{sink: sink}
+	s.batcher = newSizedBatcher(c.count, c.bytes, entrySize, c.wait, c.capacity, s.flush)
+	return s
+}
+
+// Send queues entry without blocking
+func (s *BatchSink) Send(entry Entry) error {
+	if err := s.batcher.add(entry); err != nil {
+		atomic.AddUint64(&s.stats.Dropped, 1)
+		return err
+	}
+	return nil
+}
+
+// Flush sends queued entries and flushes sink, it returns last error of sends since previous flush
+func (s *BatchSink) Flush() error {
+	err := s.batcher.sync()
+	if flusher, ok := s.sink.(interface{ Flush() error }); ok {
+		if e := flusher.Flush(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close sends queued entries and flushes and closes sink
+func (s *BatchSink) Close() error {
+	s.batcher.close()
+	return closeOutput(s.sink)
+}
+
+// Stats returns counters of sink
+func (s *BatchSink) Stats() BatchStats {
+	return loadBatchStats(&s.stats)
+}
+
+func (s *BatchSink) flush(entries []Entry) (err error) {
+	var bytes int
+	for _, entry := range entries {
+		bytes += entrySize(entry)
+	}
+	atomic.AddUint64(&s.stats.Batches, 1)
+	atomic.AddUint64(&s.stats.Entries, uint64(len(entries)))
+	atomic.AddUint64(&s.stats.Bytes, uint64(bytes))
+	if sender, ok := s.sink.(BatchSender); ok {
+		if err = sender.SendBatch(entries); err != nil {
+			atomic.AddUint64(&s.stats.Failed, uint64(len(entries)))
+			for _, entry := range entries {
+				sendFailed(entry, err)
+			}
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if e := s.sink.Send(entry); e != nil {
+			atomic.AddUint64(&s.stats.Failed, 1)
+			sendFailed(entry, e)
+			err = e
+		}
+	}
+	return err
+}
+
+// entrySize returns estimated bytes of entry
+func entrySize(entry Entry) int {
+	size := len(entry.Message) + len(entry.Source)
+	for _, f := range flattenData(entry.Data) {
+		size += len(f.key) + len(f.value)
+	}
+	return size
+}
+
+// BatchWriter implements writer which groups writes by count, bytes and wait duration
+// and writes them on writer at once, failure of background flush returns on next write
+type BatchWriter struct {
+	writer io.Writer
+	config batchConfig
+
+	mu     sync.Mutex
+	buffer []byte
+	count  int
+	err    error
+	timer  *time.Timer
+	stats  BatchStats
+}
+
+// NewBatchWriter returns new batch writer in front of writer
+func NewBatchWriter(w io.Writer, options ...BatchOption) *BatchWriter {
+	return &BatchWriter{writer: w, config: newBatchConfig(options)}
+}
+
+// Write appends p on batch and writes batch when it is full
+func (w *BatchWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.err; err != nil {
+		w.err = nil
+		return 0, err
+	}
+	w.buffer = append(w.buffer, p...)
+	w.count++
+	if w.count >= w.config.count || (w.config.bytes > 0 && len(w.buffer) >= w.config.bytes) {
+		return len(p), w.flush()
+	}
+	if w.timer == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(w.config.wait, func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if w.timer != timer {
+				return
+			}
+			if err := w.flush(); err != nil {
+				w.err = err
+			}
+		})
+		w.timer = timer
+	}
+	return len(p), nil
+}
+
+// Flush writes batch on writer and flushes writer
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close writes batch on writer and flushes and closes writer
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	err := w.flush()
+	w.mu.Unlock()
+	if e := closeOutput(w.writer); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Stats returns counters of writer
+func (w *BatchWriter) Stats() BatchStats {
+	return loadBatchStats(&w.stats)
+}
+
+func (w *BatchWriter) flush() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.count == 0 {
+		return nil
+	}
+	buffer, count := w.buffer, w.count
+	w.buffer, w.count = nil, 0
+	atomic.AddUint64(&w.stats.Batches, 1)
+	atomic.AddUint64(&w.stats.Entries, uint64(count))
+	atomic.AddUint64(&w.stats.Bytes, uint64(len(buffer)))
+	if _, err := w.writer.Write(buffer); err != nil {
+		atomic.AddUint64(&w.stats.Failed, uint64(count))
+		return err
+	}
+	return nil
+}
+
+func loadBatchStats(stats *BatchStats) BatchStats {
+	return BatchStats{
+		Batches: atomic.LoadUint64(&stats.Batches),
+		Entries: atomic.LoadUint64(&stats.Entries),
+		Bytes:   atomic.LoadUint64(&stats.Bytes),
+		Dropped: atomic.LoadUint64(&stats.Dropped),
+		Failed:  atomic.LoadUint64(&stats.Failed),
+	}
+}
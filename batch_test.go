@@ -0,0 +1,201 @@
+package log
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testBatches struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	err     error
+}
+
+func (b *testBatches) flush(entries []Entry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches = append(b.batches, entries)
+	return b.err
+}
+
+func (b *testBatches) sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var sizes []int
+	for _, batch := range b.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func Test_batcher(t *testing.T) {
+	t.Run("must flushes batches by size", func(t *testing.T) {
+		batches := new(testBatches)
+		b := newBatcher(2, time.Hour, 10, batches.flush)
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, b.add(Entry{}))
+		}
+		b.close()
+		assert.Equal(t, []int{2, 2, 1}, batches.sizes())
+	})
+	t.Run("must flushes batches by bytes", func(t *testing.T) {
+		batches := new(testBatches)
+		b := newSizedBatcher(100, 10, entrySize, time.Hour, 10, batches.flush)
+		for _, message := range []string{"12345", "12345", "123"} {
+			assert.NoError(t, b.add(Entry{Message: message}))
+		}
+		b.close()
+		assert.Equal(t, []int{2, 1}, batches.sizes())
+	})
+	t.Run("must flushes batches by wait", func(t *testing.T) {
+		batches := new(testBatches)
+		b := newBatcher(100, 10*time.Millisecond, 10, batches.flush)
+		defer b.close()
+		assert.NoError(t, b.add(Entry{}))
+		assert.Eventually(t, func() bool {
+			return len(batches.sizes()) == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("must flushes queued entries on sync", func(t *testing.T) {
+		batches := new(testBatches)
+		b := newBatcher(100, time.Hour, 10, batches.flush)
+		defer b.close()
+		assert.NoError(t, b.add(Entry{}))
+		assert.NoError(t, b.add(Entry{}))
+		assert.NoError(t, b.sync())
+		assert.Equal(t, []int{2}, batches.sizes())
+	})
+	t.Run("must returns last error of flushes on sync", func(t *testing.T) {
+		batches := &testBatches{err: errors.New("failed")}
+		b := newBatcher(1, time.Hour, 10, batches.flush)
+		defer b.close()
+		assert.NoError(t, b.add(Entry{}))
+		assert.EqualError(t, b.sync(), "failed")
+		assert.NoError(t, b.sync())
+	})
+	t.Run("must returns last error of flushes on close", func(t *testing.T) {
+		batches := &testBatches{err: errors.New("failed")}
+		b := newBatcher(10, time.Hour, 10, batches.flush)
+		assert.NoError(t, b.add(Entry{}))
+		assert.EqualError(t, b.close(), "failed")
+		assert.Equal(t, []int{1}, batches.sizes())
+	})
+	t.Run("must returns error when queue is full or closed", func(t *testing.T) {
+		block := make(chan struct{})
+		b := newBatcher(1, time.Hour, 1, func([]Entry) error {
+			<-block
+			return nil
+		})
+		assert.NoError(t, b.add(Entry{}))
+		assert.Eventually(t, func() bool {
+			return b.add(Entry{}) == nil
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, ErrQueueFull, b.add(Entry{}))
+		close(block)
+		b.close()
+		b.close()
+		assert.Equal(t, ErrQueueFull, b.add(Entry{}))
+	})
+}
+
+func Test_backoff(t *testing.T) {
+	t.Run("must returns delay between half and full of exponential delay", func(t *testing.T) {
+		for attempt := 0; attempt < 10; attempt++ {
+			delay := backoff(attempt, 100*time.Millisecond, time.Second)
+			want := 100 * time.Millisecond << uint(attempt)
+			if want > time.Second {
+				want = time.Second
+			}
+			assert.True(t, delay >= want/2 && delay <= want, "attempt %d: %v", attempt, delay)
+		}
+	})
+	t.Run("must returns zero with zero delays", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), backoff(3, 0, 0))
+	})
+}
+
+type testBatchSender struct {
+	testBatches
+	err error
+}
+
+func (s *testBatchSender) Send(entry Entry) error {
+	return errors.New("not used")
+}
+
+func (s *testBatchSender) SendBatch(entries []Entry) error {
+	s.flush(entries)
+	return s.err
+}
+
+func TestBatchSink_Send(t *testing.T) {
+	resetTest()
+	t.Run("must sends batches on batch sender", func(t *testing.T) {
+		sender := new(testBatchSender)
+		sink := NewBatchSink(sender, BatchCount(2), BatchWait(time.Hour))
+		sendTestMessages(t, sink, 1, 3)
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, []int{2, 1}, sender.sizes())
+		assert.Equal(t, BatchStats{Batches: 2, Entries: 3, Bytes: 27}, sink.Stats())
+	})
+	t.Run("must sends entries one by one on sink and reports failures", func(t *testing.T) {
+		inner := &testSink{err: errors.New("unavailable")}
+		sink := NewBatchSink(inner)
+		before := GetStats()
+		sendTestMessages(t, sink, 1, 2)
+		assert.EqualError(t, sink.Flush(), "unavailable")
+		assert.Len(t, inner.entries, 2)
+		assert.Equal(t, uint64(2), sink.Stats().Failed)
+		assert.Equal(t, before.FailedSends+2, GetStats().FailedSends)
+		assert.NoError(t, sink.Close())
+		assert.True(t, inner.closed)
+	})
+	t.Run("must drops entries when queue is full", func(t *testing.T) {
+		sink := NewBatchSink(new(testBatchSender), BatchCount(1), BatchBuffer(1))
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, ErrQueueFull, sink.Send(Entry{}))
+		assert.Equal(t, uint64(1), sink.Stats().Dropped)
+	})
+}
+
+func TestBatchWriter_Write(t *testing.T) {
+	t.Run("must writes batches by count and bytes", func(t *testing.T) {
+		var writes []string
+		w := NewBatchWriter(testWriterFunc(func(p []byte) (int, error) {
+			writes = append(writes, string(p))
+			return len(p), nil
+		}), BatchCount(3), BatchBytes(6), BatchWait(time.Hour))
+		for _, line := range []string{"a\n", "b\n", "c\n", "long\n", "d\n"} {
+			n, err := w.Write([]byte(line))
+			assert.NoError(t, err)
+			assert.Equal(t, len(line), n)
+		}
+		assert.NoError(t, w.Close())
+		assert.Equal(t, []string{"a\nb\nc\n", "long\nd\n"}, writes)
+		assert.Equal(t, BatchStats{Batches: 2, Entries: 5, Bytes: 13}, w.Stats())
+	})
+	t.Run("must writes batch after wait and returns its failure on next write", func(t *testing.T) {
+		written := make(chan string, 1)
+		w := NewBatchWriter(testWriterFunc(func(p []byte) (int, error) {
+			written <- string(p)
+			return 0, errors.New("failed")
+		}), BatchWait(10*time.Millisecond))
+		_, err := w.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "line\n", <-written)
+		assert.Eventually(t, func() bool {
+			return w.Stats().Failed == 1
+		}, time.Second, time.Millisecond)
+		_, err = w.Write([]byte("next\n"))
+		assert.EqualError(t, err, "failed")
+	})
+}
+
+type testWriterFunc func(p []byte) (int, error)
+
+func (fn testWriterFunc) Write(p []byte) (int, error) {
+	return fn(p)
+}
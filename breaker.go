@@ -0,0 +1,232 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen raises when circuit breaker rejects call because circuit is open
+var ErrCircuitOpen = errors.New("log: circuit is open")
+
+// BreakerState type of circuit breaker state
+type BreakerState int
+
+const (
+	// BreakerClosed passes calls
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen rejects calls until cooldown is passed
+	BreakerOpen
+
+	// BreakerHalfOpen passes one probe call which closes or opens circuit
+	BreakerHalfOpen
+)
+
+// String returns name of breaker state
+func (state BreakerState) String() string {
+	switch state {
+	case BreakerClosed:
+		return "Closed"
+	case BreakerOpen:
+		return "Open"
+	case BreakerHalfOpen:
+		return "HalfOpen"
+	default:
+		return "Unknown"
+	}
+}
+
+// BreakerOption type of breaker sink and breaker writer option
+type BreakerOption func(*breaker)
+
+// BreakerThreshold sets count of consecutive failures which opens circuit (default: 5)
+func BreakerThreshold(failures int) BreakerOption {
+	return func(b *breaker) {
+		b.threshold = failures
+	}
+}
+
+// BreakerCooldown sets duration of open circuit before probe call (default: 30s)
+func BreakerCooldown(cooldown time.Duration) BreakerOption {
+	return func(b *breaker) {
+		b.cooldown = cooldown
+	}
+}
+
+// BreakerStats keeps counters and state of breaker sink and breaker writer
+type BreakerStats struct {
+	// State keeps current state of circuit
+	State BreakerState
+
+	// Calls keeps count of passed calls
+	Calls uint64
+
+	// Failures keeps count of failed calls
+	Failures uint64
+
+	// Rejected keeps count of calls which are rejected by open circuit
+	Rejected uint64
+
+	// Trips keeps count of circuit openings
+	Trips uint64
+}
+
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	stats    BreakerStats
+}
+
+func newBreaker(options []BreakerOption) *breaker {
+	b := &breaker{threshold: 5, cooldown: 30 * time.Second}
+	for _, option := range options {
+		option(b)
+	}
+	if b.threshold < 1 {
+		b.threshold = 1
+	}
+	return b
+}
+
+// do calls fn when circuit is closed or probe of half open circuit is due, otherwise returns ErrCircuitOpen
+func (b *breaker) do(fn func() error) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		b.stats.State = BreakerClosed
+		return nil
+	}
+	b.stats.Failures++
+	b.failures++
+	if b.stats.State == BreakerHalfOpen || b.failures >= b.threshold {
+		b.stats.State = BreakerOpen
+		b.stats.Trips++
+		b.openedAt = time.Now()
+	}
+	return err
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stats.State == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		b.stats.State = BreakerHalfOpen
+	}
+	if b.stats.State == BreakerOpen || (b.stats.State == BreakerHalfOpen && b.probing) {
+		b.stats.Rejected++
+		return false
+	}
+	b.probing = b.stats.State == BreakerHalfOpen
+	b.stats.Calls++
+	return true
+}
+
+func (b *breaker) load() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// BreakerSink implements sink which stops sending on failing sink and probes it after cooldown,
+// entries which are rejected by open circuit fail with ErrCircuitOpen
+type BreakerSink struct {
+	sink    Sink
+	breaker *breaker
+}
+
+// NewBreakerSink returns new breaker sink in front of sink
+func NewBreakerSink(sink Sink, options ...BreakerOption) *BreakerSink {
+	return &BreakerSink{sink: sink, breaker: newBreaker(options)}
+}
+
+// Send sends entry on sink when circuit allows
+func (s *BreakerSink) Send(entry Entry) error {
+	return s.breaker.do(func() error {
+		return s.sink.Send(entry)
+	})
+}
+
+// SendBatch sends batch of entries on sink when circuit allows
+func (s *BreakerSink) SendBatch(entries []Entry) error {
+	if sender, ok := s.sink.(BatchSender); ok {
+		return s.breaker.do(func() error {
+			return sender.SendBatch(entries)
+		})
+	}
+	for _, entry := range entries {
+		if err := s.Send(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes sink
+func (s *BreakerSink) Flush() error {
+	if flusher, ok := s.sink.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes sink
+func (s *BreakerSink) Close() error {
+	return closeOutput(s.sink)
+}
+
+// Stats returns counters and state of sink
+func (s *BreakerSink) Stats() BreakerStats {
+	return s.breaker.load()
+}
+
+// BreakerWriter implements writer which stops writing on failing writer and probes it after cooldown,
+// writes which are rejected by open circuit fail with ErrCircuitOpen
+type BreakerWriter struct {
+	writer  io.Writer
+	breaker *breaker
+}
+
+// NewBreakerWriter returns new breaker writer in front of writer
+func NewBreakerWriter(w io.Writer, options ...BreakerOption) *BreakerWriter {
+	return &BreakerWriter{writer: w, breaker: newBreaker(options)}
+}
+
+// Write writes p on writer when circuit allows
+func (w *BreakerWriter) Write(p []byte) (n int, err error) {
+	err = w.breaker.do(func() error {
+		n, err = w.writer.Write(p)
+		return err
+	})
+	return n, err
+}
+
+// Flush flushes writer
+func (w *BreakerWriter) Flush() error {
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes writer
+func (w *BreakerWriter) Close() error {
+	return closeOutput(w.writer)
+}
+
+// Stats returns counters and state of writer
+func (w *BreakerWriter) Stats() BreakerStats {
+	return w.breaker.load()
+}
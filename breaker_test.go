@@ -0,0 +1,59 @@
+package log
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBreakerSink_Send(t *testing.T) {
+	failing := true
+	sink := NewBreakerSink(testSinkFunc(func(entry Entry) error {
+		if failing {
+			return errors.New("unavailable")
+		}
+		return nil
+	}), BreakerThreshold(2), BreakerCooldown(20*time.Millisecond))
+
+	t.Run("must opens circuit after consecutive failures", func(t *testing.T) {
+		assert.Error(t, sink.Send(Entry{}))
+		assert.Equal(t, BreakerClosed, sink.Stats().State)
+		assert.Error(t, sink.Send(Entry{}))
+		assert.Equal(t, BreakerOpen, sink.Stats().State)
+		assert.Equal(t, ErrCircuitOpen, sink.Send(Entry{}))
+		assert.Equal(t, BreakerStats{State: BreakerOpen, Calls: 2, Failures: 2, Rejected: 1, Trips: 1}, sink.Stats())
+	})
+	t.Run("must opens circuit again when probe fails", func(t *testing.T) {
+		time.Sleep(25 * time.Millisecond)
+		assert.Equal(t, "unavailable", sink.Send(Entry{}).Error())
+		assert.Equal(t, BreakerOpen, sink.Stats().State)
+		assert.Equal(t, uint64(2), sink.Stats().Trips)
+	})
+	t.Run("must closes circuit when probe succeeds", func(t *testing.T) {
+		failing = false
+		time.Sleep(25 * time.Millisecond)
+		assert.NoError(t, sink.Send(Entry{}))
+		assert.Equal(t, BreakerClosed, sink.Stats().State)
+		assert.NoError(t, sink.Send(Entry{}))
+	})
+}
+
+func TestBreakerWriter_Write(t *testing.T) {
+	w := NewBreakerWriter(testWriterFunc(func(p []byte) (int, error) {
+		return 0, errors.New("unavailable")
+	}), BreakerThreshold(1), BreakerCooldown(time.Hour))
+	_, err := w.Write([]byte("message"))
+	assert.EqualError(t, err, "unavailable")
+	n, err := w.Write([]byte("message"))
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, BreakerOpen, w.Stats().State)
+}
+
+func TestBreakerState_String(t *testing.T) {
+	assert.Equal(t, "Closed", BreakerClosed.String())
+	assert.Equal(t, "Open", BreakerOpen.String())
+	assert.Equal(t, "HalfOpen", BreakerHalfOpen.String())
+	assert.Equal(t, "Unknown", BreakerState(10).String())
+}
@@ -0,0 +1,270 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	constantService = "service"
+	constantVersion = "version"
+	constantEnv     = "env"
+	ecsVersion      = "1.6.0"
+)
+
+// GCPOption type of google cloud formatter option
+type GCPOption func(*gcpFormatter)
+
+// GCPProject sets project id which prefixes trace as projects/<id>/traces/<trace>
+func GCPProject(id string) GCPOption {
+	return func(f *gcpFormatter) {
+		f.project = id
+	}
+}
+
+// NewGCPFormatter returns new google cloud logging structured json formatter,
+// service and version constants are written in serviceContext and other constants as labels
+func NewGCPFormatter(options ...GCPOption) Formatter {
+	f := new(gcpFormatter)
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+type gcpFormatter struct {
+	project string
+}
+
+func (f gcpFormatter) Format(entry Entry) string {
+	return formatObject(entry, f.object)
+}
+
+func (f gcpFormatter) object(entry Entry) *jsonObject {
+	meta, data := splitConstants(entry.Data)
+	obj := &jsonObject{}
+	obj.add("time", entry.Raised.Format(time.RFC3339Nano))
+	obj.add("severity", gcpSeverity(entry.Level))
+	obj.add("message", strings.TrimSpace(entry.Message))
+	if function, file, line := sourceLocation(entry.Source); file != "" {
+		obj.add("logging.googleapis.com/sourceLocation", map[string]string{
+			"file":     file,
+			"line":     strconv.Itoa(line),
+			"function": function,
+		})
+	}
+	if trace, ok := data[traceKeys.TraceID]; ok {
+		delete(data, traceKeys.TraceID)
+		if f.project != "" {
+			trace = fmt.Sprintf("projects/%s/traces/%v", f.project, trace)
+		}
+		obj.add("logging.googleapis.com/trace", trace)
+	}
+	moveData(obj, data, traceKeys.SpanID, "logging.googleapis.com/spanId")
+	if flags, ok := traceFlags(data[traceKeys.Flags]); ok {
+		delete(data, traceKeys.Flags)
+		obj.add("logging.googleapis.com/trace_sampled", flags&1 == 1)
+	}
+	service := make(map[string]interface{})
+	for _, key := range []string{constantService, constantVersion} {
+		if value, ok := meta[key]; ok {
+			delete(meta, key)
+			service[key] = value
+		}
+	}
+	if len(service) > 0 {
+		obj.add("serviceContext", service)
+	}
+	if labels := stringLabels(meta); len(labels) > 0 {
+		obj.add("logging.googleapis.com/labels", labels)
+	}
+	addData(obj, data)
+	return obj
+}
+
+func gcpSeverity(lvl Level) string {
+	switch lvl {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarning:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "CRITICAL"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// NewECSFormatter returns new elastic common schema json formatter,
+// service, version and env constants are written as service fields and other constants as labels
+func NewECSFormatter() Formatter {
+	return new(ecsFormatter)
+}
+
+type ecsFormatter struct {
+}
+
+func (ecsFormatter) Format(entry Entry) string {
+	return formatObject(entry, ecsFormatter{}.object)
+}
+
+func (ecsFormatter) object(entry Entry) *jsonObject {
+	meta, data := splitConstants(entry.Data)
+	obj := &jsonObject{}
+	obj.add("@timestamp", entry.Raised.Format(time.RFC3339Nano))
+	obj.add("log.level", strings.ToLower(entry.Level.String()))
+	obj.add("message", strings.TrimSpace(entry.Message))
+	obj.add("ecs.version", ecsVersion)
+	if function, file, line := sourceLocation(entry.Source); file != "" {
+		obj.add("log.origin.file.name", file)
+		obj.add("log.origin.file.line", line)
+		obj.add("log.origin.function", function)
+	}
+	moveData(obj, data, dataError, "error.message")
+	moveData(obj, data, traceKeys.TraceID, "trace.id")
+	moveData(obj, data, traceKeys.SpanID, "span.id")
+	moveData(obj, meta, constantService, "service.name")
+	moveData(obj, meta, constantVersion, "service.version")
+	moveData(obj, meta, constantEnv, "service.environment")
+	if labels := stringLabels(meta); len(labels) > 0 {
+		obj.add("labels", labels)
+	}
+	addData(obj, data)
+	return obj
+}
+
+// NewDatadogFormatter returns new datadog json formatter,
+// service, version and env constants are written as reserved attributes and other constants as tags
+func NewDatadogFormatter() Formatter {
+	return new(datadogFormatter)
+}
+
+type datadogFormatter struct {
+}
+
+func (datadogFormatter) Format(entry Entry) string {
+	return formatObject(entry, datadogFormatter{}.object)
+}
+
+func (datadogFormatter) object(entry Entry) *jsonObject {
+	meta, data := splitConstants(entry.Data)
+	obj := &jsonObject{}
+	obj.add("timestamp", entry.Raised.Format(time.RFC3339Nano))
+	obj.add("status", datadogStatus(entry.Level))
+	obj.add("message", strings.TrimSpace(entry.Message))
+	if function, file, line := sourceLocation(entry.Source); file != "" {
+		obj.add("logger.method_name", function)
+		obj.add("logger.file_name", fmt.Sprintf("%s:%d", file, line))
+	}
+	moveData(obj, data, dataError, "error.message")
+	if trace, ok := data[traceKeys.TraceID]; ok {
+		delete(data, traceKeys.TraceID)
+		obj.add("dd.trace_id", datadogID(trace))
+	}
+	if span, ok := data[traceKeys.SpanID]; ok {
+		delete(data, traceKeys.SpanID)
+		obj.add("dd.span_id", datadogID(span))
+	}
+	moveData(obj, meta, constantService, "service")
+	moveData(obj, meta, constantVersion, "version")
+	moveData(obj, meta, constantEnv, "env")
+	if labels := stringLabels(meta); len(labels) > 0 {
+		tags := make([]string, 0, len(labels))
+		for key, value := range labels {
+			tags = append(tags, key+":"+value)
+		}
+		sort.Strings(tags)
+		obj.add("ddtags", strings.Join(tags, ","))
+	}
+	addData(obj, data)
+	return obj
+}
+
+func datadogStatus(lvl Level) string {
+	switch lvl {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "critical"
+	default:
+		return "info"
+	}
+}
+
+// datadogID returns decimal of lower 64 bits of hex id which datadog uses for correlation
+func datadogID(id interface{}) string {
+	hex := fmt.Sprint(id)
+	if len(hex) > 16 {
+		hex = hex[len(hex)-16:]
+	}
+	if value, err := strconv.ParseUint(hex, 16, 64); err == nil {
+		return strconv.FormatUint(value, 10)
+	}
+	return fmt.Sprint(id)
+}
+
+func formatObject(entry Entry, object func(Entry) *jsonObject) string {
+	bytes, err := object(entry).marshal(false)
+	if err != nil {
+		bytes, _ = object(encodeFailed(entry, err)).marshal(false)
+	}
+	return string(bytes)
+}
+
+// splitConstants returns constants which are not changed in data and rest of data
+func splitConstants(data map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	meta := make(map[string]interface{})
+	rest := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if constant, ok := constants[key]; ok && reflect.DeepEqual(constant, value) {
+			meta[key] = value
+			continue
+		}
+		rest[key] = value
+	}
+	return meta, rest
+}
+
+func stringLabels(data map[string]interface{}) map[string]string {
+	labels := make(map[string]string, len(data))
+	for key, value := range data {
+		labels[key] = fmt.Sprint(value)
+	}
+	return labels
+}
+
+// moveData moves key of data into object with name
+func moveData(obj *jsonObject, data map[string]interface{}, key, name string) {
+	if value, ok := data[key]; ok {
+		delete(data, key)
+		obj.add(name, value)
+	}
+}
+
+// addData adds sorted data keys into object, keys of object are not replaced
+func addData(obj *jsonObject, data map[string]interface{}) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !obj.has(key) {
+			obj.add(key, data[key])
+		}
+	}
+}
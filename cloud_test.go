@@ -0,0 +1,130 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func createTestCloudEntry() Entry {
+	return Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123000000, time.UTC),
+		Level:   LevelError,
+		Source:  "at main.run in /app/main.go:42",
+		Message: "can not connect",
+		Data: map[string]interface{}{
+			"service":   "api",
+			"version":   "1.2.0",
+			"env":       "prod",
+			"region":    "eu",
+			"error":     "connection refused",
+			"trace_id":  "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":   "00f067aa0ba902b7",
+			"attempt":   3,
+			"component": "db",
+		},
+	}
+}
+
+func setTestCloudConstants() {
+	resetTest()
+	SetConstant("service", "api")
+	SetConstant("version", "1.2.0")
+	SetConstant("env", "prod")
+	SetConstant("region", "eu")
+}
+
+func TestNewGCPFormatter(t *testing.T) {
+	t.Run("must returns google cloud formatter", func(t *testing.T) {
+		assert.Equal(t, NewGCPFormatter(GCPProject("my-project")), &gcpFormatter{project: "my-project"})
+	})
+}
+
+func Test_gcpFormatter_Format(t *testing.T) {
+	setTestCloudConstants()
+	t.Run("must returns google cloud structured document", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"time": "2020-04-10T12:30:45.123Z",
+			"severity": "ERROR",
+			"message": "can not connect",
+			"logging.googleapis.com/sourceLocation": {"file": "/app/main.go", "line": "42", "function": "main.run"},
+			"logging.googleapis.com/trace": "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+			"logging.googleapis.com/spanId": "00f067aa0ba902b7",
+			"serviceContext": {"service": "api", "version": "1.2.0"},
+			"logging.googleapis.com/labels": {"env": "prod", "region": "eu"},
+			"error": "connection refused",
+			"attempt": 3,
+			"component": "db"
+		}`, NewGCPFormatter(GCPProject("my-project")).Format(createTestCloudEntry()))
+	})
+	t.Run("must returns severities of levels", func(t *testing.T) {
+		want := map[Level]string{LevelDebug: "DEBUG", LevelInfo: "INFO", LevelWarning: "WARNING", LevelError: "ERROR", LevelFatal: "CRITICAL", 10: "DEFAULT"}
+		for lvl, severity := range want {
+			assert.Equal(t, severity, gcpSeverity(lvl))
+		}
+	})
+}
+
+func TestNewECSFormatter(t *testing.T) {
+	t.Run("must returns elastic common schema formatter", func(t *testing.T) {
+		assert.Equal(t, NewECSFormatter(), new(ecsFormatter))
+	})
+}
+
+func Test_ecsFormatter_Format(t *testing.T) {
+	setTestCloudConstants()
+	t.Run("must returns elastic common schema document", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"@timestamp": "2020-04-10T12:30:45.123Z",
+			"log.level": "error",
+			"message": "can not connect",
+			"ecs.version": "1.6.0",
+			"log.origin.file.name": "/app/main.go",
+			"log.origin.file.line": 42,
+			"log.origin.function": "main.run",
+			"error.message": "connection refused",
+			"trace.id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span.id": "00f067aa0ba902b7",
+			"service.name": "api",
+			"service.version": "1.2.0",
+			"service.environment": "prod",
+			"labels": {"region": "eu"},
+			"attempt": 3,
+			"component": "db"
+		}`, NewECSFormatter().Format(createTestCloudEntry()))
+	})
+}
+
+func TestNewDatadogFormatter(t *testing.T) {
+	t.Run("must returns datadog formatter", func(t *testing.T) {
+		assert.Equal(t, NewDatadogFormatter(), new(datadogFormatter))
+	})
+}
+
+func Test_datadogFormatter_Format(t *testing.T) {
+	setTestCloudConstants()
+	t.Run("must returns datadog document", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"timestamp": "2020-04-10T12:30:45.123Z",
+			"status": "error",
+			"message": "can not connect",
+			"logger.method_name": "main.run",
+			"logger.file_name": "/app/main.go:42",
+			"error.message": "connection refused",
+			"dd.trace_id": "11803532876627986230",
+			"dd.span_id": "67667974448284343",
+			"service": "api",
+			"version": "1.2.0",
+			"env": "prod",
+			"ddtags": "region:eu",
+			"attempt": 3,
+			"component": "db"
+		}`, NewDatadogFormatter().Format(createTestCloudEntry()))
+	})
+	t.Run("must not treats changed constants as metadata", func(t *testing.T) {
+		entry := createTestCloudEntry()
+		entry.Data = map[string]interface{}{"service": "worker"}
+		assert.Contains(t, NewDatadogFormatter().Format(entry), `"service":"worker"`)
+		assert.NotContains(t, NewDatadogFormatter().Format(entry), "ddtags")
+	})
+}
@@ -0,0 +1,240 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ElasticOption type of elasticsearch sink option
+type ElasticOption func(*ElasticSink)
+
+// ElasticIndex sets index pattern, %Y, %m, %d and %H are replaced with utc raised time of entry (default: logs-%Y.%m.%d)
+func ElasticIndex(pattern string) ElasticOption {
+	return func(s *ElasticSink) {
+		s.index = pattern
+	}
+}
+
+// ElasticFormatter sets json formatter of documents (default: ecs formatter)
+func ElasticFormatter(f Formatter) ElasticOption {
+	return func(s *ElasticSink) {
+		s.formatter = f
+	}
+}
+
+// ElasticBasicAuth sets username and password of requests
+func ElasticBasicAuth(username, password string) ElasticOption {
+	return func(s *ElasticSink) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// ElasticBatch sets maximum size and wait duration of batches (default: 500, 1s)
+func ElasticBatch(size int, wait time.Duration) ElasticOption {
+	return func(s *ElasticSink) {
+		s.size = size
+		s.wait = wait
+	}
+}
+
+// ElasticBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 10000)
+func ElasticBuffer(capacity int) ElasticOption {
+	return func(s *ElasticSink) {
+		s.capacity = capacity
+	}
+}
+
+// ElasticRetry sets retries of failed requests and documents and their backoff delays (default: 5, 500ms, 30s)
+func ElasticRetry(retries int, min, max time.Duration) ElasticOption {
+	return func(s *ElasticSink) {
+		s.retry = httpRetry{retries: retries, min: min, max: max}
+	}
+}
+
+// ElasticClient sets http client of requests (default: client with 10s timeout)
+func ElasticClient(client *http.Client) ElasticOption {
+	return func(s *ElasticSink) {
+		s.client = client
+	}
+}
+
+// ElasticStats keeps counters of elasticsearch sink
+type ElasticStats struct {
+	// Indexed keeps count of indexed entries
+	Indexed uint64
+
+	// Retried keeps count of retried entries
+	Retried uint64
+
+	// Dropped keeps count of entries which are not indexed
+	Dropped uint64
+}
+
+// ElasticSink implements sink of elasticsearch and opensearch bulk api,
+// entries are batched and only failed documents of bulk responses are retried
+type ElasticSink struct {
+	url       string
+	index     string
+	formatter Formatter
+	username  string
+	password  string
+	size      int
+	wait      time.Duration
+	capacity  int
+	retry     httpRetry
+	client    *http.Client
+
+	batcher *batcher
+	stats   ElasticStats
+}
+
+// NewElasticSink returns new elasticsearch sink which indexes on server of url
+func NewElasticSink(url string, options ...ElasticOption) *ElasticSink {
+	s := &ElasticSink{
+		url:       strings.TrimRight(url, "/") + "/_bulk",
+		index:     "logs-%Y.%m.%d",
+		formatter: NewECSFormatter(),
+		size:      500,
+		wait:      time.Second,
+		capacity:  10000,
+		retry:     httpRetry{retries: 5, min: 500 * time.Millisecond, max: 30 * time.Second},
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.formatter = sinkFormatter(s.formatter)
+	s.batcher = newBatcher(s.size, s.wait, s.capacity, s.flush)
+	return s
+}
+
+// Send queues entry to index in next batch
+func (s *ElasticSink) Send(entry Entry) error {
+	if err := s.batcher.add(entry); err != nil {
+		atomic.AddUint64(&s.stats.Dropped, 1)
+		return err
+	}
+	return nil
+}
+
+// Flush indexes queued entries, it returns last error of failed entries since previous flush
+func (s *ElasticSink) Flush() error {
+	return s.batcher.sync()
+}
+
+// Close indexes queued entries and stops sink, it returns last error of failed entries since previous flush
+func (s *ElasticSink) Close() error {
+	return s.batcher.close()
+}
+
+// Stats returns counters of sink
+func (s *ElasticSink) Stats() ElasticStats {
+	return ElasticStats{
+		Indexed: atomic.LoadUint64(&s.stats.Indexed),
+		Retried: atomic.LoadUint64(&s.stats.Retried),
+		Dropped: atomic.LoadUint64(&s.stats.Dropped),
+	}
+}
+
+type elasticResponse struct {
+	Items []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// flush indexes entries and retries failed items, it returns error when entries are not indexed
+// except items which are rejected with non-retryable status because retrying can not index them
+func (s *ElasticSink) flush(entries []Entry) error {
+	for attempt := 0; len(entries) > 0; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&s.stats.Retried, uint64(len(entries)))
+			time.Sleep(backoff(attempt-1, s.retry.min, s.retry.max))
+		}
+		failed, err := s.bulk(entries)
+		if err != nil {
+			s.drop(entries, err)
+			return err
+		}
+		if len(failed) > 0 && attempt >= s.retry.retries {
+			err := fmt.Errorf("log: bulk item is not indexed after %d retries", s.retry.retries)
+			s.drop(failed, err)
+			return err
+		}
+		entries = failed
+	}
+	return nil
+}
+
+// bulk indexes entries and returns entries of retryable failed items, entries of other failed items are dropped
+func (s *ElasticSink) bulk(entries []Entry) ([]Entry, error) {
+	var body bytes.Buffer
+	for _, entry := range entries {
+		action, _ := json.Marshal(map[string]interface{}{"create": map[string]string{"_index": elasticIndex(s.index, entry.Raised)}})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.WriteString(strings.ReplaceAll(s.formatter.Format(entry), "\n", ""))
+		body.WriteByte('\n')
+	}
+	payload := body.Bytes()
+	raw, err := s.retry.do(s.client, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if s.username != "" {
+			req.SetBasicAuth(s.username, s.password)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var res elasticResponse
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Items) != len(entries) {
+		return nil, fmt.Errorf("log: bulk response has %d items of %d entries", len(res.Items), len(entries))
+	}
+	var failed []Entry
+	for i, item := range res.Items {
+		for _, result := range item {
+			switch {
+			case result.Status >= 200 && result.Status <= 299:
+				atomic.AddUint64(&s.stats.Indexed, 1)
+			case retryableStatus(result.Status):
+				failed = append(failed, entries[i])
+			default:
+				s.drop(entries[i:i+1], &StatusError{Code: result.Status, Body: string(result.Error)})
+			}
+		}
+	}
+	return failed, nil
+}
+
+func (s *ElasticSink) drop(entries []Entry, err error) {
+	atomic.AddUint64(&s.stats.Dropped, uint64(len(entries)))
+	for _, entry := range entries {
+		sendFailed(entry, err)
+	}
+}
+
+// elasticIndex returns index of pattern with utc time
+func elasticIndex(pattern string, raised time.Time) string {
+	raised = raised.UTC()
+	return strings.NewReplacer(
+		"%Y", fmt.Sprintf("%04d", raised.Year()),
+		"%m", fmt.Sprintf("%02d", raised.Month()),
+		"%d", fmt.Sprintf("%02d", raised.Day()),
+		"%H", fmt.Sprintf("%02d", raised.Hour()),
+		"%%", "%",
+	).Replace(pattern)
+}
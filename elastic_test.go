@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testElasticDocument struct {
+	index    string
+	document map[string]interface{}
+}
+
+// startTestElasticServer emulates bulk api, documents with retry message fail once with 429
+// and documents with invalid message fail with 400
+func startTestElasticServer(t *testing.T) (*httptest.Server, <-chan testElasticDocument) {
+	documents := make(chan testElasticDocument, 100)
+	var mu sync.Mutex
+	retried := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		var items []map[string]interface{}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]map[string]string
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			assert.True(t, scanner.Scan())
+			var document map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &document))
+			status := http.StatusCreated
+			mu.Lock()
+			switch message := document["message"].(string); {
+			case message == "invalid":
+				status = http.StatusBadRequest
+			case message == "retry" && !retried[message]:
+				retried[message] = true
+				status = http.StatusTooManyRequests
+			default:
+				documents <- testElasticDocument{index: action["create"]["_index"], document: document}
+			}
+			mu.Unlock()
+			items = append(items, map[string]interface{}{"create": map[string]interface{}{"status": status}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": true, "items": items})
+	}))
+	return server, documents
+}
+
+func TestElasticSink_Send(t *testing.T) {
+	resetTest()
+	entry := func(message string) Entry {
+		return Entry{
+			Raised:  time.Date(2020, 4, 10, 12, 30, 45, 0, time.UTC),
+			Level:   LevelInfo,
+			Message: message,
+			Data:    map[string]interface{}{"key": "value"},
+		}
+	}
+	server, documents := startTestElasticServer(t)
+	defer server.Close()
+	sink := NewElasticSink(server.URL, ElasticIndex("logs-%Y.%m.%d-%H"), ElasticBatch(3, time.Hour),
+		ElasticRetry(2, time.Millisecond, time.Millisecond))
+	defer sink.Close()
+	before := GetStats()
+	assert.NoError(t, sink.Send(entry("text message")))
+	assert.NoError(t, sink.Send(entry("retry")))
+	assert.NoError(t, sink.Send(entry("invalid")))
+	assert.NoError(t, sink.Flush())
+	var messages []string
+	for i := 0; i < 2; i++ {
+		document := <-documents
+		assert.Equal(t, "logs-2020.04.10-12", document.index)
+		assert.Equal(t, "value", document.document["key"])
+		messages = append(messages, document.document["message"].(string))
+	}
+	assert.Equal(t, []string{"text message", "retry"}, messages)
+	assert.Equal(t, ElasticStats{Indexed: 2, Retried: 1, Dropped: 1}, sink.Stats())
+	assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+}
+
+func TestElasticSink_Close(t *testing.T) {
+	resetTest()
+	t.Run("must returns error of failed bulk request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		sink := NewElasticSink(server.URL, ElasticRetry(0, time.Millisecond, time.Millisecond))
+		before := GetStats()
+		assert.NoError(t, sink.Send(Entry{Message: "text message"}))
+		assert.Error(t, sink.Close())
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+}
+
+func Test_elasticIndex(t *testing.T) {
+	raised := time.Date(2020, 4, 10, 12, 30, 45, 0, time.FixedZone("", 3600*14))
+	assert.Equal(t, "logs-2020.04.09", elasticIndex("logs-%Y.%m.%d", raised))
+	assert.Equal(t, "logs-2020-04-09T22%", elasticIndex("logs-%Y-%m-%dT%H%%", raised))
+	assert.Equal(t, "logs", elasticIndex("logs", raised))
+}
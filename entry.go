@@ -1,10 +1,12 @@
 package log
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,18 +29,16 @@ type Entry struct {
 	// Message keeps log message text
 	Message string
 
-	// Data keeps all data
+	// Data keeps data added on entry, data of parent entries is shared and not copied
 	Data map[string]interface{}
+
+	parent *Entry
+	group  []string
 }
 
-// NewEntry returns new entry with defaults
+// NewEntry returns new entry with defaults, source is captured when entry logs
 func NewEntry() *Entry {
-	var src string
-	if pc, file, line, ok := runtime.Caller(2); ok {
-		src = fmt.Sprintf("at %v in %v:%d", runtime.FuncForPC(pc).Name(), file, line)
-	}
 	entry := &Entry{
-		Source: src,
 		Raised: time.Now(),
 		Data:   make(map[string]interface{}),
 	}
@@ -48,6 +48,20 @@ func NewEntry() *Entry {
 	return entry
 }
 
+// sourceLocation returns function, file and line of entry source
+func sourceLocation(src string) (function, file string, line int) {
+	parts := strings.SplitN(strings.TrimPrefix(src, "at "), " in ", 2)
+	if len(parts) != 2 {
+		return "", "", 0
+	}
+	function, file = parts[0], parts[1]
+	if i := strings.LastIndexByte(file, ':'); i >= 0 {
+		line, _ = strconv.Atoi(file[i+1:])
+		file = file[:i]
+	}
+	return function, file, line
+}
+
 // Debug logs entry with message in debug level
 func (entry Entry) Debug(message string) {
 	entry.log(LevelDebug, message)
@@ -68,19 +82,21 @@ func (entry Entry) Error(message string) {
 	entry.log(LevelError, message)
 }
 
-// Fatal logs entry with message in fatal level so exit with code 1
+// Fatal logs entry with message in fatal level so runs exit handlers and exits with exit code
 func (entry Entry) Fatal(message string) {
 	entry.log(LevelFatal, message)
-	exit(1)
+	Exit(exitCode)
 }
 
-// With appends data to entry and returns that
+// With returns child of entry with appended data, entry itself is not changed
 func (entry *Entry) With(data interface{}) *Entry {
-	switch value := data.(type) {
-	case nil:
+	if data == nil {
 		return entry
+	}
+	child := entry.child()
+	switch value := data.(type) {
 	case error:
-		entry.Data[dataError] = value.Error()
+		child.set(dataError, value.Error())
 	default:
 		if reflect.TypeOf(value).Kind() == reflect.Ptr {
 			value = reflect.ValueOf(value).Elem().Interface()
@@ -91,33 +107,141 @@ func (entry *Entry) With(data interface{}) *Entry {
 		case reflect.Map:
 			dic := reflect.ValueOf(value)
 			for _, key := range dic.MapKeys() {
-				entry.Data[key.String()] = dic.MapIndex(key).Interface()
+				child.set(key.String(), dic.MapIndex(key).Interface())
 			}
 		case reflect.Struct:
-			entry.Data[refType.Name()] = value
+			child.set(refType.Name(), value)
 		default:
 			var values []interface{}
-			if entry.Data[dataValues] != nil {
-				values = entry.Data[dataValues].([]interface{})
+			if inherited, ok := entry.lookup(dataValues).([]interface{}); ok {
+				values = append(values, inherited...)
 			}
-			entry.Data[dataValues] = append(values, value)
+			child.set(dataValues, append(values, value))
 		}
 	}
-	return entry
+	return child
 }
 
-// Value appends key, value to entry and returns that
+// Value returns child of entry with appended key, value, entry itself is not changed
 func (entry *Entry) Value(key string, value interface{}) *Entry {
-	entry.Data[key] = value
-	return entry
+	child := entry.child()
+	child.set(key, value)
+	return child
+}
+
+// Group returns child of entry which nests next data under name
+func (entry *Entry) Group(name string) *Entry {
+	if name == "" {
+		return entry
+	}
+	child := entry.child()
+	child.group = append(append(make([]string, 0, len(entry.group)+1), entry.group...), name)
+	return child
+}
+
+// Trace returns child of entry with trace context under trace keys, invalid trace context is ignored
+func (entry *Entry) Trace(tc TraceContext) *Entry {
+	if !tc.Valid() {
+		return entry
+	}
+	child := entry.child()
+	for key, value := range map[string]string{
+		traceKeys.TraceID: tc.TraceID,
+		traceKeys.SpanID:  tc.SpanID,
+		traceKeys.Flags:   fmt.Sprintf("%02x", tc.Flags),
+		traceKeys.State:   tc.State,
+	} {
+		if key != "" && value != "" {
+			child.Data[key] = value
+		}
+	}
+	return child
+}
+
+// Context returns child of entry with trace context which is carried by context
+func (entry *Entry) Context(ctx context.Context) *Entry {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return entry
+	}
+	return entry.Trace(tc)
+}
+
+// Fields returns all data of entry merged with data of parent entries
+func (entry *Entry) Fields() map[string]interface{} {
+	var chain []*Entry
+	for e := entry; e != nil; e = e.parent {
+		chain = append(chain, e)
+	}
+	fields := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		mergeData(fields, chain[i].Data)
+	}
+	return fields
 }
 
+func (entry *Entry) child() *Entry {
+	return &Entry{
+		Level:   entry.Level,
+		Message: entry.Message,
+		Data:    make(map[string]interface{}, 1),
+		parent:  entry,
+		group:   entry.group,
+	}
+}
+
+func (entry *Entry) set(key string, value interface{}) {
+	data := Namespace(entry.Data)
+	if _, ok := constants[key]; ok && len(entry.group) == 0 {
+		switch collision {
+		case CollisionKeep:
+			return
+		case CollisionRename:
+			data = data.child(dataFields)
+		}
+	}
+	for _, name := range entry.group {
+		data = data.child(name)
+	}
+	data[key] = value
+}
+
+func (entry *Entry) lookup(key string) interface{} {
+	for e := entry; e != nil; e = e.parent {
+		data := Namespace(e.Data)
+		for _, name := range entry.group {
+			data, _ = data[name].(Namespace)
+		}
+		if value, ok := data[key]; ok {
+			return value
+		}
+	}
+	return nil
+}
+
+// log writes entry on output and sinks, it must be called directly from logging functions of callers
+// so source of caller is captured with fixed skip
 func (entry *Entry) log(lvl Level, msg string) {
 	entry.Level = lvl
 	entry.Message = msg
+	if !enabled(entry.Level) {
+		return
+	}
+	if entry.Raised.IsZero() {
+		entry.Raised = time.Now()
+	}
+	if entry.Source == "" {
+		if pc, file, line, ok := runtime.Caller(2); ok {
+			entry.Source = fmt.Sprintf("at %v in %v:%d", runtime.FuncForPC(pc).Name(), file, line)
+		}
+	}
+	entry.Data = resolveLazy(entry.Fields())
+	entry.parent = nil
 	if entry.Level >= level {
-		if _, err := fmt.Fprintln(output, formatter.Format(*entry)); err != nil {
-			log.Printf("can not write on output: %v", err)
+		line := formatter.Format(*entry)
+		if _, err := fmt.Fprintln(output, line); err != nil {
+			writeFailed(*entry, line, err)
 		}
 	}
+	send(*entry)
 }
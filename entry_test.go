@@ -1,9 +1,13 @@
 package log
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -141,7 +145,6 @@ func TestNewEntry(t *testing.T) {
 			name: "must returns new entry with defaults",
 			want: &Entry{
 				Raised: time.Now(),
-				Source: "at .* in .*[.]go:[\\d]*",
 				Data:   make(map[string]interface{}),
 			},
 		},
@@ -153,7 +156,209 @@ func TestNewEntry(t *testing.T) {
 			assert.Equal(t, entry.Message, tt.want.Message)
 			assert.Equal(t, entry.Level, tt.want.Level)
 			assert.Equal(t, entry.Raised.Format("2006-01-02 15:04:05"), tt.want.Raised.Format("2006-01-02 15:04:05"))
-			assert.Regexp(t, tt.want.Source, entry.Source)
+			assert.Equal(t, tt.want.Source, entry.Source)
+		})
+	}
+}
+
+// logTestEntry logs entry in info level and returns source of call
+func logTestEntry(entry *Entry) string {
+	pc, file, line, _ := runtime.Caller(0)
+	entry.Info("text message")
+	return fmt.Sprintf("at %v in %v:%d", runtime.FuncForPC(pc).Name(), file, line+1)
+}
+
+func TestEntry_log(t *testing.T) {
+	resetTest()
+	ring := NewRingSink(10)
+	AddSink(ring, LevelDebug)
+	last := func() Entry {
+		entries := ring.Entries()
+		return entries[len(entries)-1]
+	}
+	t.Run("must captures source of caller", func(t *testing.T) {
+		pc, file, line, _ := runtime.Caller(0)
+		Info("text message")
+		assert.Equal(t, fmt.Sprintf("at %v in %v:%d", runtime.FuncForPC(pc).Name(), file, line+1), last().Source)
+	})
+	t.Run("must captures source of derived entry where it logs", func(t *testing.T) {
+		base := Value("svc", "api")
+		assert.Equal(t, logTestEntry(base), last().Source)
+		assert.Empty(t, base.Source)
+	})
+	t.Run("must captures raised time of derived entry where it logs", func(t *testing.T) {
+		base := Value("svc", "api")
+		started := time.Now()
+		base.Info("text message")
+		assert.False(t, last().Raised.Before(started))
+	})
+	t.Run("must keeps raised time and source which are set", func(t *testing.T) {
+		entry := createTestEntry()
+		entry.Raised = time.Date(2020, 4, 10, 12, 0, 0, 0, time.UTC)
+		entry.Info("text message")
+		assert.Equal(t, entry.Raised, last().Raised)
+		assert.Equal(t, entry.Source, last().Source)
+	})
+}
+
+func TestEntry_child(t *testing.T) {
+	resetTest()
+	t.Run("must not changes parent entry", func(t *testing.T) {
+		base := createTestEntry().Value("svc", "api")
+		first := base.Value("first", 1).With("value1")
+		second := base.Value("second", 2).With("value2")
+		assert.Equal(t, map[string]interface{}{"svc": "api"}, base.Fields())
+		assert.Equal(t, map[string]interface{}{"svc": "api", "first": 1, dataValues: []interface{}{"value1"}}, first.Fields())
+		assert.Equal(t, map[string]interface{}{"svc": "api", "second": 2, dataValues: []interface{}{"value2"}}, second.Fields())
+	})
+	t.Run("must overrides parent data in child", func(t *testing.T) {
+		entry := createTestEntry().Value("key", "parent").Value("key", "child")
+		assert.Equal(t, "child", entry.Fields()["key"])
+	})
+	t.Run("must logs shared entry from goroutines", func(t *testing.T) {
+		base := createTestEntry().Value("svc", "api")
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				entry := base.Value("index", i).With(i)
+				assert.Equal(t, []interface{}{i}, entry.Fields()[dataValues])
+			}(i)
+		}
+		wg.Wait()
+		assert.Equal(t, map[string]interface{}{"svc": "api"}, base.Fields())
+	})
+}
+
+func TestEntry_Fields(t *testing.T) {
+	resetTest()
+	t.Run("must returns merged data of entry and parents", func(t *testing.T) {
+		entry := createTestEntry().Value("key1", "value1").With(map[string]interface{}{"key2": "value2"})
+		assert.Equal(t, map[string]interface{}{"key1": "value1", "key2": "value2"}, entry.Fields())
+	})
+}
+
+var benchEntry *Entry
+
+func BenchmarkEntry_Value(b *testing.B) {
+	base := createTestEntry()
+	for i := 0; i < 50; i++ {
+		base = base.Value(fmt.Sprintf("key%d", i), i)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchEntry = base.Value("request", i)
+	}
+}
+
+func BenchmarkEntry_Fields(b *testing.B) {
+	base := createTestEntry()
+	for i := 0; i < 50; i++ {
+		base = base.Value(fmt.Sprintf("key%d", i), i)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		base.Value("request", i).Fields()
+	}
+}
+
+func TestEntry_Group(t *testing.T) {
+	resetTest()
+	t.Run("must nests data under group", func(t *testing.T) {
+		entry := createTestEntry().Value("key", "value").Group("db").Value("query", "select").With(errors.New("failed"))
+		assert.Equal(t, map[string]interface{}{
+			"key": "value",
+			"db":  Namespace{"query": "select", dataError: "failed"},
+		}, entry.Fields())
+	})
+	t.Run("must nests data under nested groups", func(t *testing.T) {
+		db := createTestEntry().Group("db")
+		entry := db.Value("query", "select").Group("pool").Value("size", 10)
+		assert.Equal(t, map[string]interface{}{
+			"db": Namespace{"query": "select", "pool": Namespace{"size": 10}},
+		}, entry.Fields())
+		assert.Equal(t, map[string]interface{}{}, db.Fields())
+	})
+	t.Run("must appends values in group", func(t *testing.T) {
+		entry := createTestEntry().With("value1").Group("db").With("value2").With("value3")
+		assert.Equal(t, map[string]interface{}{
+			dataValues: []interface{}{"value1"},
+			"db":       Namespace{dataValues: []interface{}{"value2", "value3"}},
+		}, entry.Fields())
+	})
+	t.Run("must returns same entry with empty name", func(t *testing.T) {
+		entry := createTestEntry()
+		assert.Equal(t, entry, entry.Group(""))
+	})
+	t.Run("must returns nested data in output", func(t *testing.T) {
+		createTestEntry().Group("db").Value("query", "select").Info("text message")
+		assert.Contains(t, testOutput.String(), "\"Data\":{\"db\":{\"query\":\"select\"}}")
+		testOutput.Reset()
+	})
+}
+
+func TestEntry_Trace(t *testing.T) {
+	resetTest()
+	tc := TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Flags: 1}
+	t.Run("must adds trace context under trace keys out of group", func(t *testing.T) {
+		entry := createTestEntry().Group("db").Trace(tc)
+		assert.Equal(t, map[string]interface{}{
+			"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":     "00f067aa0ba902b7",
+			"trace_flags": "01",
+		}, entry.Fields())
+	})
+	t.Run("must adds trace context under configured keys", func(t *testing.T) {
+		SetTraceKeys(TraceKeys{TraceID: "traceId", SpanID: "spanId", State: "traceState"})
+		defer SetTraceKeys(defaultTraceKeys())
+		tc := tc
+		tc.State = "vendor=value"
+		assert.Equal(t, map[string]interface{}{
+			"traceId":    "4bf92f3577b34da6a3ce929d0e0e4736",
+			"spanId":     "00f067aa0ba902b7",
+			"traceState": "vendor=value",
+		}, createTestEntry().Trace(tc).Fields())
+	})
+	t.Run("must returns same entry with invalid trace context", func(t *testing.T) {
+		entry := createTestEntry()
+		assert.Equal(t, entry, entry.Trace(TraceContext{}))
+	})
+	t.Run("must adds trace context of context", func(t *testing.T) {
+		entry := createTestEntry().Context(ContextWithTrace(context.Background(), tc))
+		assert.Equal(t, "00f067aa0ba902b7", entry.Fields()["span_id"])
+		assert.Equal(t, entry, entry.Context(context.Background()))
+	})
+}
+
+func Test_sourceLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		function string
+		file     string
+		line     int
+	}{
+		{
+			name:     "must returns location of source",
+			src:      "at main.main in /go/src/my app/main.go:10",
+			function: "main.main",
+			file:     "/go/src/my app/main.go",
+			line:     10,
+		},
+		{
+			name: "must returns empty location of invalid source",
+			src:  "invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function, file, line := sourceLocation(tt.src)
+			assert.Equal(t, tt.function, function)
+			assert.Equal(t, tt.file, file)
+			assert.Equal(t, tt.line, line)
 		})
 	}
 }
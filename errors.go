@@ -0,0 +1,90 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+var (
+	// ErrWrite raises when entry can not write on output
+	ErrWrite = errors.New("log: can not write on output")
+
+	// ErrEncode raises when formatter can not encode entry
+	ErrEncode = errors.New("log: can not encode entry")
+
+	// ErrSend raises when entry can not send on sink
+	ErrSend = errors.New("log: can not send on sink")
+)
+
+const dataEncodeError = "encode_error"
+
+// ErrorHandler type of function which handles failures of logging entry
+type ErrorHandler func(Entry, error)
+
+// Stats keeps counters of logging failures
+type Stats struct {
+	// FailedWrites keeps count of entries which can not write on output
+	FailedWrites uint64
+
+	// FailedEncodings keeps count of entries which can not encode by formatter
+	FailedEncodings uint64
+
+	// FailedSends keeps count of entries which can not send on sinks
+	FailedSends uint64
+}
+
+var (
+	errorHandler    ErrorHandler
+	fallback        io.Writer = os.Stderr
+	failedWrites    uint64
+	failedEncodings uint64
+	failedSends     uint64
+)
+
+// SetErrorHandler sets handler of logging failures
+func SetErrorHandler(h ErrorHandler) {
+	errorHandler = h
+}
+
+// SetFallbackOutput sets output which uses when writing on output fails (default: os.Stderr)
+func SetFallbackOutput(w io.Writer) {
+	fallback = w
+}
+
+// GetStats returns counters of logging failures
+func GetStats() Stats {
+	return Stats{
+		FailedWrites:    atomic.LoadUint64(&failedWrites),
+		FailedEncodings: atomic.LoadUint64(&failedEncodings),
+		FailedSends:     atomic.LoadUint64(&failedSends),
+	}
+}
+
+func writeFailed(entry Entry, line string, err error) {
+	atomic.AddUint64(&failedWrites, 1)
+	if errorHandler != nil {
+		errorHandler(entry, fmt.Errorf("%w: %v", ErrWrite, err))
+	}
+	if fallback != nil && fallback != output {
+		_, _ = fmt.Fprintln(fallback, line)
+	}
+}
+
+func sendFailed(entry Entry, err error) {
+	atomic.AddUint64(&failedSends, 1)
+	if errorHandler != nil {
+		errorHandler(entry, fmt.Errorf("%w: %v", ErrSend, err))
+	}
+}
+
+func encodeFailed(entry Entry, err error) Entry {
+	atomic.AddUint64(&failedEncodings, 1)
+	if errorHandler != nil {
+		errorHandler(entry, fmt.Errorf("%w: %v", ErrEncode, err))
+	}
+	entry.Data = map[string]interface{}{dataEncodeError: err.Error()}
+	return entry
+}
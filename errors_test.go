@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type testFailedWriter struct{}
+
+func (testFailedWriter) Write([]byte) (int, error) {
+	return 0, errors.New("closed pipe")
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	resetTest()
+	t.Run("must handles write failures", func(t *testing.T) {
+		var handled []error
+		SetErrorHandler(func(entry Entry, err error) {
+			assert.Equal(t, "text message", entry.Message)
+			handled = append(handled, err)
+		})
+		SetOutput(testFailedWriter{})
+		before := GetStats()
+		Info("text message")
+		assert.Len(t, handled, 1)
+		assert.True(t, errors.Is(handled[0], ErrWrite))
+		assert.Contains(t, handled[0].Error(), "closed pipe")
+		assert.Equal(t, before.FailedWrites+1, GetStats().FailedWrites)
+	})
+}
+
+func TestSetFallbackOutput(t *testing.T) {
+	resetTest()
+	t.Run("must writes on fallback when output fails", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		SetFallbackOutput(buf)
+		SetOutput(testFailedWriter{})
+		Info("text message")
+		assert.Contains(t, buf.String(), "\"Message\":\"text message\"")
+	})
+}
+
+func TestEncodeFailures(t *testing.T) {
+	resetTest()
+	entry := Entry{Message: "text message", Data: map[string]interface{}{"channel": make(chan int)}}
+	formatters := map[string]Formatter{
+		"json": NewJSONFormatter(),
+		"yaml": NewYAMLFormatter(),
+		"text": NewTextFormatter(),
+	}
+	for name, f := range formatters {
+		t.Run("must returns degraded line of "+name+" formatter", func(t *testing.T) {
+			var handled error
+			SetErrorHandler(func(entry Entry, err error) {
+				handled = err
+			})
+			before := GetStats()
+			line := f.Format(entry)
+			assert.Contains(t, line, "text message")
+			assert.Contains(t, line, dataEncodeError)
+			assert.False(t, strings.Contains(line, "channel"))
+			assert.True(t, errors.Is(handled, ErrEncode))
+			assert.Equal(t, before.FailedEncodings+1, GetStats().FailedEncodings)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	exitCode     = 1
+	exitTimeout  = 5 * time.Second
+	exitHandlers []func()
+)
+
+// RegisterExitHandler appends handler which runs before exit on fatal logs
+func RegisterExitHandler(handler func()) {
+	exitHandlers = append(exitHandlers, handler)
+}
+
+// SetExitCode sets exit code of fatal logs (default: 1)
+func SetExitCode(code int) {
+	exitCode = code
+}
+
+// SetExitTimeout sets maximum duration of running all exit handlers and closing output and sinks (default: 5s)
+func SetExitTimeout(d time.Duration) {
+	exitTimeout = d
+}
+
+// SetExitFunc sets function which exits process (default: os.Exit)
+func SetExitFunc(fn func(code int)) {
+	exit = fn
+}
+
+// Exit runs exit handlers in order, closes output then exits process with code, it exits after exit timeout
+// even if handlers or closing output are not done
+func Exit(code int) {
+	deadline := time.Now().Add(exitTimeout)
+	handlers, out, all := exitHandlers, output, sinks
+	runUntil(deadline, func() { runExitHandlers(handlers) })
+	runUntil(deadline, func() { _ = closeAll(out, all) })
+	exit(code)
+}
+
+// Close flushes and closes output and sinks, standard outputs are only synced
+func Close() error {
+	return closeAll(output, sinks)
+}
+
+func closeAll(out io.Writer, sinks []levelSink) error {
+	err := closeOutput(out)
+	for _, s := range sinks {
+		if e := closeOutput(s.sink); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func closeOutput(out interface{}) (err error) {
+	if flusher, ok := out.(interface{ Flush() error }); ok {
+		err = flusher.Flush()
+	}
+	switch w := out.(type) {
+	case *os.File:
+		if w == os.Stdout || w == os.Stderr {
+			_ = w.Sync()
+			return err
+		}
+		if e := w.Close(); err == nil {
+			err = e
+		}
+	case io.Closer:
+		if e := w.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func runExitHandlers(handlers []func()) {
+	for _, handler := range handlers {
+		runExitHandler(handler)
+	}
+}
+
+// runUntil runs fn in background and waits until it is done or deadline is passed
+func runUntil(deadline time.Time, fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+func runExitHandler(handler func()) {
+	defer func() {
+		_ = recover()
+	}()
+	handler()
+}
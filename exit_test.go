@@ -0,0 +1,103 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testCloser struct {
+	flushed bool
+	closed  bool
+}
+
+func (w *testCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *testCloser) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func (w *testCloser) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestRegisterExitHandler(t *testing.T) {
+	resetTest()
+	t.Run("must runs handlers in order before exit", func(t *testing.T) {
+		var calls []string
+		RegisterExitHandler(func() { calls = append(calls, "first") })
+		RegisterExitHandler(func() { panic("failed") })
+		RegisterExitHandler(func() { calls = append(calls, "second") })
+		SetExitFunc(func(code int) { calls = append(calls, "exit") })
+		Fatal("fatal message")
+		assert.Equal(t, []string{"first", "second", "exit"}, calls)
+	})
+}
+
+func TestSetExitTimeout(t *testing.T) {
+	resetTest()
+	t.Run("must exits after timeout of handlers", func(t *testing.T) {
+		exited := false
+		SetExitTimeout(10 * time.Millisecond)
+		RegisterExitHandler(func() { time.Sleep(time.Second) })
+		SetExitFunc(func(code int) { exited = true })
+		started := time.Now()
+		Fatal("fatal message")
+		assert.True(t, exited)
+		assert.True(t, time.Since(started) < time.Second)
+	})
+	t.Run("must exits after timeout of closing sinks", func(t *testing.T) {
+		resetTest()
+		exited := false
+		block := make(chan struct{})
+		defer close(block)
+		SetExitTimeout(10 * time.Millisecond)
+		AddSink(&testBlockingSink{block: block}, LevelDebug)
+		SetExitFunc(func(code int) { exited = true })
+		started := time.Now()
+		Fatal("fatal message")
+		assert.True(t, exited)
+		assert.True(t, time.Since(started) < time.Second)
+	})
+}
+
+type testBlockingSink struct {
+	block chan struct{}
+}
+
+func (s *testBlockingSink) Send(entry Entry) error {
+	return nil
+}
+
+func (s *testBlockingSink) Close() error {
+	<-s.block
+	return nil
+}
+
+func TestSetExitCode(t *testing.T) {
+	resetTest()
+	t.Run("must exits with exit code", func(t *testing.T) {
+		code := 0
+		SetExitCode(3)
+		SetExitFunc(func(c int) { code = c })
+		Fatal("fatal message")
+		assert.Equal(t, 3, code)
+	})
+}
+
+func TestExit(t *testing.T) {
+	resetTest()
+	t.Run("must flushes and closes output before exit", func(t *testing.T) {
+		w := new(testCloser)
+		SetOutput(w)
+		SetExitFunc(func(code int) {
+			assert.True(t, w.flushed)
+			assert.True(t, w.closed)
+		})
+		Exit(0)
+	})
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// field keeps flattened key and text value of data
+type field struct {
+	key   string
+	value string
+}
+
+// flattenData returns data flattened into dotted keys with text values sorted by key
+func flattenData(data map[string]interface{}) []field {
+	var fields []field
+	for key, value := range data {
+		fields = flattenValue(fields, key, value)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].key < fields[j].key
+	})
+	return fields
+}
+
+// flattenValue appends value flattened into dotted keys, pointers and maps which refer to themselves
+// are written as their address
+func flattenValue(fields []field, key string, value interface{}) []field {
+	return flattenVisited(fields, key, value, make(map[uintptr]bool))
+}
+
+// flattenVisited appends value flattened into dotted keys, visited keeps addresses of pointers and maps
+// on path of value to detect cycles
+func flattenVisited(fields []field, key string, value interface{}, visited map[uintptr]bool) []field {
+	switch v := value.(type) {
+	case nil:
+		return append(fields, field{key, ""})
+	case string:
+		return append(fields, field{key, v})
+	case error:
+		return append(fields, field{key, v.Error()})
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return append(fields, field{key, string(text)})
+		}
+	case fmt.Stringer:
+		return append(fields, field{key, v.String()})
+	}
+
+	ref := reflect.ValueOf(value)
+	for ref.Kind() == reflect.Ptr || ref.Kind() == reflect.Interface {
+		if ref.IsNil() {
+			return append(fields, field{key, ""})
+		}
+		if ref.Kind() == reflect.Ptr {
+			if visited[ref.Pointer()] {
+				return append(fields, field{key, fmt.Sprintf("%p", ref.Interface())})
+			}
+			visited[ref.Pointer()] = true
+			defer delete(visited, ref.Pointer())
+		}
+		ref = ref.Elem()
+	}
+	switch ref.Kind() {
+	case reflect.Map:
+		if visited[ref.Pointer()] {
+			return append(fields, field{key, fmt.Sprintf("%p", ref.Interface())})
+		}
+		visited[ref.Pointer()] = true
+		defer delete(visited, ref.Pointer())
+		for _, k := range ref.MapKeys() {
+			fields = flattenVisited(fields, fmt.Sprintf("%s.%v", key, k.Interface()), ref.MapIndex(k).Interface(), visited)
+		}
+		return fields
+	case reflect.Struct:
+		refType := ref.Type()
+		for i := 0; i < refType.NumField(); i++ {
+			if refType.Field(i).PkgPath != "" {
+				continue
+			}
+			name := refType.Field(i).Name
+			if tag := strings.Split(refType.Field(i).Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			fields = flattenVisited(fields, key+"."+name, ref.Field(i).Interface(), visited)
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		bytes, err := json.Marshal(ref.Interface())
+		if err == nil {
+			return append(fields, field{key, string(bytes)})
+		}
+		switch ref.Type().Elem().Kind() {
+		case reflect.Interface, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Struct:
+			// elements may refer to slice itself which fmt does not detect
+			return append(fields, field{key, err.Error()})
+		}
+	}
+	return append(fields, field{key, fmt.Sprint(ref.Interface())})
+}
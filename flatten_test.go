@@ -0,0 +1,77 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_flattenData(t *testing.T) {
+	raised := time.Date(2020, 4, 10, 12, 30, 45, 0, time.UTC)
+	type user struct {
+		Name    string `json:"name"`
+		Secret  string `json:"-"`
+		Age     int
+		private string
+	}
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want []field
+	}{
+		{
+			name: "must returns sorted scalar fields",
+			data: map[string]interface{}{"b": 1, "a": "text", "c": nil, "d": true},
+			want: []field{{"a", "text"}, {"b", "1"}, {"c", ""}, {"d", "true"}},
+		},
+		{
+			name: "must returns text of errors and times",
+			data: map[string]interface{}{"error": errors.New("failed"), "time": raised},
+			want: []field{{"error", "failed"}, {"time", "2020-04-10T12:30:45Z"}},
+		},
+		{
+			name: "must returns dotted keys of maps and structs",
+			data: map[string]interface{}{
+				"db":   Namespace{"query": "select"},
+				"map":  map[int]string{1: "one"},
+				"user": &user{Name: "john", Secret: "secret", Age: 20, private: "private"},
+			},
+			want: []field{{"db.query", "select"}, {"map.1", "one"}, {"user.Age", "20"}, {"user.name", "john"}},
+		},
+		{
+			name: "must returns json of slices",
+			data: map[string]interface{}{"values": []interface{}{"a", 1}},
+			want: []field{{"values", `["a",1]`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flattenData(tt.data))
+		})
+	}
+	t.Run("must returns address of values which refer to themselves", func(t *testing.T) {
+		type node struct {
+			Name string
+			Next *node
+		}
+		n := &node{Name: "loop"}
+		n.Next = n
+		m := map[string]interface{}{"name": "loop"}
+		m["self"] = m
+		s := []interface{}{"loop", nil}
+		s[1] = s
+		assert.Equal(t, []field{
+			{"map.name", "loop"}, {"map.self", fmt.Sprintf("%p", m)},
+			{"node.Name", "loop"}, {"node.Next", fmt.Sprintf("%p", n)},
+			{"slice", "json: unsupported value: encountered a cycle via []interface {}"},
+		}, flattenData(map[string]interface{}{"node": n, "map": m, "slice": s}))
+		assert.Contains(t, NewLogfmtFormatter().Format(Entry{Data: map[string]interface{}{"node": n}}), "node.Next=0x")
+	})
+	t.Run("must returns shared values which do not refer to themselves", func(t *testing.T) {
+		shared := &struct{ Name string }{"shared"}
+		assert.Equal(t, []field{{"a.Name", "shared"}, {"b.Name", "shared"}},
+			flattenData(map[string]interface{}{"a": shared, "b": shared}))
+	})
+}
@@ -0,0 +1,199 @@
+package log
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrFluentAck raises when fluent server does not acknowledge chunk
+var ErrFluentAck = errors.New("log: fluent chunk is not acknowledged")
+
+// FluentOption type of fluent sink option
+type FluentOption func(*FluentSink)
+
+// FluentBatch sets maximum size and wait duration of batches (default: 100, 1s)
+func FluentBatch(size int, wait time.Duration) FluentOption {
+	return func(s *FluentSink) {
+		s.size = size
+		s.wait = wait
+	}
+}
+
+// FluentBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 10000)
+func FluentBuffer(capacity int) FluentOption {
+	return func(s *FluentSink) {
+		s.capacity = capacity
+	}
+}
+
+// FluentAck requires acknowledgement of chunks from server for at-least-once delivery
+func FluentAck() FluentOption {
+	return func(s *FluentSink) {
+		s.ack = true
+	}
+}
+
+// FluentTimeout sets timeout of dialing, writing and reading acknowledgements (default: 5s)
+func FluentTimeout(timeout time.Duration) FluentOption {
+	return func(s *FluentSink) {
+		s.timeout = timeout
+	}
+}
+
+// FluentRetry sets retries of failed batches and their backoff delays (default: 5, 100ms, 10s)
+func FluentRetry(retries int, min, max time.Duration) FluentOption {
+	return func(s *FluentSink) {
+		s.retries = retries
+		s.minBackoff = min
+		s.maxBackoff = max
+	}
+}
+
+// FluentSink implements sink of fluentd and fluent bit forward protocol, entries are batched in packed forward mode
+// as [tag, time, record] events with event time and failed batches are reconnected and retried with backoff
+type FluentSink struct {
+	network    string
+	address    string
+	tag        string
+	size       int
+	wait       time.Duration
+	capacity   int
+	ack        bool
+	timeout    time.Duration
+	retries    int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	conn    net.Conn
+	batcher *batcher
+}
+
+// NewFluentSink returns new fluent sink connected on network and address which sends entries with tag
+func NewFluentSink(network, address, tag string, options ...FluentOption) (*FluentSink, error) {
+	s := &FluentSink{
+		network:    network,
+		address:    address,
+		tag:        tag,
+		size:       100,
+		wait:       time.Second,
+		capacity:   10000,
+		timeout:    5 * time.Second,
+		retries:    5,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	s.batcher = newBatcher(s.size, s.wait, s.capacity, s.flush)
+	return s, nil
+}
+
+// Send queues entry to send in next batch
+func (s *FluentSink) Send(entry Entry) error {
+	return s.batcher.add(entry)
+}
+
+// Flush sends queued entries, it returns last error of failed entries since previous flush
+func (s *FluentSink) Flush() error {
+	return s.batcher.sync()
+}
+
+// Close sends queued entries and closes connection
+func (s *FluentSink) Close() error {
+	s.batcher.close()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func (s *FluentSink) connect() (err error) {
+	s.conn, err = net.DialTimeout(s.network, s.address, s.timeout)
+	return err
+}
+
+func (s *FluentSink) flush(entries []Entry) error {
+	var events []byte
+	for _, entry := range entries {
+		events = appendMsgpackHeader(events, 2, 0x90, 0xdc, 0xdd)
+		events = appendMsgpack(events, eventTime(entry.Raised))
+		events = appendMsgpack(events, fluentRecord(entry))
+	}
+	option := map[string]interface{}{"size": len(entries)}
+	var chunk string
+	if s.ack {
+		id := make([]byte, 16)
+		_, _ = rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+	msg := appendMsgpackHeader(nil, 3, 0x90, 0xdc, 0xdd)
+	msg = appendMsgpackString(msg, s.tag)
+	msg = appendMsgpackBinary(msg, events)
+	msg = appendMsgpack(msg, option)
+
+	for attempt := 0; ; attempt++ {
+		err := s.write(msg, chunk)
+		if err == nil {
+			return nil
+		}
+		if s.conn != nil {
+			_ = s.conn.Close()
+			s.conn = nil
+		}
+		if attempt >= s.retries {
+			for _, entry := range entries {
+				sendFailed(entry, err)
+			}
+			return err
+		}
+		time.Sleep(backoff(attempt, s.minBackoff, s.maxBackoff))
+	}
+}
+
+func (s *FluentSink) write(msg []byte, chunk string) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if s.timeout > 0 {
+		_ = s.conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+	if _, err := s.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	response, err := decodeMsgpack(bufio.NewReader(s.conn))
+	if err != nil {
+		return err
+	}
+	if values, ok := response.(map[string]interface{}); !ok || values["ack"] != chunk {
+		return ErrFluentAck
+	}
+	return nil
+}
+
+func fluentRecord(entry Entry) map[string]interface{} {
+	record := make(map[string]interface{}, len(entry.Data)+3)
+	for key, value := range entry.Data {
+		record[key] = value
+	}
+	record["message"] = strings.TrimSpace(entry.Message)
+	record["level"] = strings.ToLower(entry.Level.String())
+	if entry.Source != "" {
+		record["source"] = entry.Source
+	}
+	return record
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testFluentEvent struct {
+	tag    string
+	time   time.Time
+	record map[string]interface{}
+}
+
+// startTestFluentServer decodes packed forward messages, it drops first connection without ack when flaky is set
+func startTestFluentServer(t *testing.T, flaky bool) (net.Listener, <-chan testFluentEvent) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	events := make(chan testFluentEvent, 100)
+	var mu sync.Mutex
+	dropped := false
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					value, err := decodeMsgpack(reader)
+					if err != nil {
+						return
+					}
+					msg := value.([]interface{})
+					option := msg[2].(map[string]interface{})
+					mu.Lock()
+					drop := flaky && !dropped
+					dropped = true
+					mu.Unlock()
+					if drop {
+						return
+					}
+					stream := bufio.NewReader(bytes.NewReader(msg[1].([]byte)))
+					for {
+						event, err := decodeMsgpack(stream)
+						if err != nil {
+							break
+						}
+						pair := event.([]interface{})
+						raised, _ := eventTimeOf(pair[0])
+						events <- testFluentEvent{tag: msg[0].(string), time: raised, record: pair[1].(map[string]interface{})}
+					}
+					if chunk, ok := option["chunk"]; ok {
+						_, _ = conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": chunk}))
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener, events
+}
+
+func TestFluentSink_Send(t *testing.T) {
+	raised := time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC)
+	entry := Entry{
+		Raised:  raised,
+		Level:   LevelWarning,
+		Source:  "at test in test.go:10",
+		Message: "text message",
+		Data:    map[string]interface{}{"key": "value", "count": 3},
+	}
+	receive := func(events <-chan testFluentEvent) testFluentEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("event is not received")
+			return testFluentEvent{}
+		}
+	}
+	t.Run("must sends batched events with event time", func(t *testing.T) {
+		listener, events := startTestFluentServer(t, false)
+		defer listener.Close()
+		sink, err := NewFluentSink("tcp", listener.Addr().String(), "app.logs", FluentBatch(2, time.Hour))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry))
+		assert.NoError(t, sink.Send(entry))
+		for i := 0; i < 2; i++ {
+			event := receive(events)
+			assert.Equal(t, "app.logs", event.tag)
+			assert.True(t, raised.Equal(event.time))
+			assert.Equal(t, map[string]interface{}{
+				"message": "text message",
+				"level":   "warning",
+				"source":  "at test in test.go:10",
+				"key":     "value",
+				"count":   int64(3),
+			}, event.record)
+		}
+	})
+	t.Run("must retries unacknowledged chunks on new connection", func(t *testing.T) {
+		listener, events := startTestFluentServer(t, true)
+		defer listener.Close()
+		sink, err := NewFluentSink("tcp", listener.Addr().String(), "app.logs",
+			FluentAck(), FluentRetry(3, time.Millisecond, 10*time.Millisecond), FluentTimeout(time.Second))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry))
+		assert.NoError(t, sink.Flush())
+		assert.Equal(t, "text message", receive(events).record["message"])
+	})
+	t.Run("must reports failed entries after retries", func(t *testing.T) {
+		resetTest()
+		listener, _ := startTestFluentServer(t, false)
+		sink, err := NewFluentSink("tcp", listener.Addr().String(), "app.logs",
+			FluentRetry(1, time.Millisecond, time.Millisecond), FluentTimeout(100*time.Millisecond))
+		assert.NoError(t, err)
+		_ = listener.Close()
+		_ = sink.conn.Close()
+		before := GetStats()
+		assert.NoError(t, sink.Send(entry))
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+}
+
+func TestNewFluentSink(t *testing.T) {
+	t.Run("must returns error when server is not available", func(t *testing.T) {
+		_, err := NewFluentSink("tcp", "127.0.0.1:1", "tag", FluentTimeout(time.Second))
+		assert.Error(t, err)
+	})
+}
+
+// eventTimeOf returns time of decoded fluent event time extension
+func eventTimeOf(value interface{}) (time.Time, bool) {
+	ext, ok := value.(msgpackExt)
+	if !ok || ext.Type != 0 || len(ext.Data) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(binary.BigEndian.Uint32(ext.Data[:4])), int64(binary.BigEndian.Uint32(ext.Data[4:]))), true
+}
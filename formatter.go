@@ -1,10 +1,11 @@
 package log
 
 import (
-	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"os"
 	"strings"
+	"time"
 )
 
 // Formatter interface of entry to string formatter
@@ -13,49 +14,90 @@ type Formatter interface {
 	Format(Entry) string
 }
 
-// NewTextFormatter returns new text formatter
-func NewTextFormatter() Formatter {
-	return new(textFormatter)
+// NewTextFormatter returns new text formatter with options
+func NewTextFormatter(options ...TextOption) Formatter {
+	return newTextFormatter(options...)
 }
 
-type textFormatter struct {
+// newTextFormatter returns text formatter which resolves FORCE_COLOR and NO_COLOR environments of auto coloring
+func newTextFormatter(options ...TextOption) *textFormatter {
+	f := new(textFormatter)
+	for _, option := range options {
+		option(f)
+	}
+	if f.colors == ColorAuto {
+		if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" {
+			f.colors = ColorAlways
+		} else if os.Getenv("NO_COLOR") != "" {
+			f.colors = ColorNever
+		}
+	}
+	return f
 }
 
-func (textFormatter) Format(entry Entry) string {
-	raised := entry.Raised.Format("2006-01-02 15:04:05.999-07:00")
-
-	level := strings.ToUpper(entry.Level.String())
-	switch entry.Level {
-	case LevelFatal:
-		level = fmt.Sprintf("\033[1;31m%s\033[0m", level)
-	case LevelError:
-		level = fmt.Sprintf("\033[0;31m%s\033[0m", level)
-	case LevelWarning:
-		level = fmt.Sprintf("\033[0;33m%s\033[0m", level)
-	case LevelInfo:
-		level = fmt.Sprintf("\033[0;36m%s\033[0m", level)
-	case LevelDebug:
-		level = fmt.Sprintf("\033[0;37m%s\033[0m", level)
+// sinkFormatter returns formatter of sink which does not color automatically, since sinks do not write on terminals
+func sinkFormatter(f Formatter) Formatter {
+	switch f := f.(type) {
+	case *textFormatter:
+		return f.plain()
+	case *templateFormatter:
+		return f.plain()
 	}
+	return f
+}
+
+type textFormatter struct {
+	colors     ColorMode
+	palette    map[Level]string
+	layout     string
+	location   *time.Location
+	singleLine bool
+	hideSource bool
+}
 
-	raw := fmt.Sprintf("%v | %v | %v \n\t%v", raised, level, strings.TrimSpace(entry.Message), entry.Source)
+func (f textFormatter) Format(entry Entry) string {
+	raw := fmt.Sprintf("%v | %v | %v", f.raised(entry), f.level(entry), strings.TrimSpace(entry.Message))
+	if f.singleLine {
+		if inline := f.inline(entry); inline != "" {
+			raw = fmt.Sprintf("%s %s", raw, inline)
+		}
+		return raw
+	}
+	if !f.hideSource {
+		raw = fmt.Sprintf("%s \n\t%v", raw, entry.Source)
+	}
 	if entry.Data != nil && len(entry.Data) > 0 {
-		bytes, _ := yaml.Marshal(entry.Data)
+		bytes, err := marshalYAML(flattenNamespaces(entry.Data))
+		if err != nil {
+			bytes, _ = marshalYAML(encodeFailed(entry, err).Data)
+		}
 		raw = fmt.Sprintf("%s\n\t%s", raw, strings.TrimSpace(strings.ReplaceAll(string(bytes), "\n", "\n\t")))
 	}
 	return raw
 }
 
-// NewJSONFormatter returns new json formatter
-func NewJSONFormatter() Formatter {
-	return new(jsonFormatter)
+// NewJSONFormatter returns new json formatter with options
+func NewJSONFormatter(options ...JSONOption) Formatter {
+	f := new(jsonFormatter)
+	for _, option := range options {
+		option(f)
+	}
+	return f
 }
 
 type jsonFormatter struct {
+	keys        JSONKeys
+	levelString bool
+	inlineData  bool
+	time        TimeEncoding
+	pretty      bool
 }
 
-func (jsonFormatter) Format(entry Entry) string {
-	bytes, _ := json.Marshal(entry)
+func (f jsonFormatter) Format(entry Entry) string {
+	bytes, err := f.format(entry)
+	if err != nil {
+		bytes, _ = f.format(encodeFailed(entry, err))
+	}
 	return string(bytes)
 }
 
@@ -68,6 +110,19 @@ type yamlFormatter struct {
 }
 
 func (yamlFormatter) Format(entry Entry) string {
-	bytes, _ := yaml.Marshal(entry)
+	bytes, err := marshalYAML(entry)
+	if err != nil {
+		bytes, _ = marshalYAML(encodeFailed(entry, err))
+	}
 	return string(bytes)
 }
+
+// marshalYAML returns yaml marshal of value, panics of unsupported types return as error
+func marshalYAML(value interface{}) (bytes []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return yaml.Marshal(value)
+}
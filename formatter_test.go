@@ -153,3 +153,38 @@ func Test_yamlFormatter_Format(t *testing.T) {
 		})
 	}
 }
+
+func Test_textFormatter_Format_namespace(t *testing.T) {
+	t.Run("must returns dotted keys of namespaces", func(t *testing.T) {
+		text := new(textFormatter).Format(Entry{
+			Data: map[string]interface{}{"db": Namespace{"query": "select"}},
+		})
+		assert.Contains(t, text, "db.query: select")
+	})
+}
+
+func Test_sinkFormatter(t *testing.T) {
+	defer resetTest()
+	outputTerminal = true
+	entry := Entry{Level: LevelError, Message: "message"}
+	tmpl, err := NewTemplateFormatter(`{{color .Level .Message}}`)
+	assert.NoError(t, err)
+	tests := []struct {
+		name      string
+		formatter Formatter
+		want      bool
+	}{
+		{"must not colors text formatter automatically", NewTextFormatter(), false},
+		{"must not colors template formatter automatically", tmpl, false},
+		{"must keeps colors of text formatter which always colors", NewTextFormatter(TextColors(ColorAlways)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, strings.Contains(sinkFormatter(tt.formatter).Format(entry), "\033["))
+		})
+	}
+	t.Run("must not changes formatter of output", func(t *testing.T) {
+		_ = sinkFormatter(tmpl)
+		assert.Equal(t, "\033[0;31mmessage\033[0m", tmpl.Format(entry))
+	})
+}
@@ -0,0 +1,289 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gelfVersion     = "1.1"
+	gelfChunkSize   = 1420
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+	dataStack       = "stack"
+)
+
+var (
+	// ErrGELFTooLarge raises when gelf message needs more than 128 chunks
+	ErrGELFTooLarge = errors.New("log: gelf message is too large")
+
+	// ErrGELFChunkSize raises when gelf chunk size is not greater than 12 bytes of chunk header
+	ErrGELFChunkSize = errors.New("log: gelf chunk size is too small")
+)
+
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFOption type of gelf formatter option
+type GELFOption func(*gelfFormatter)
+
+// GELFHost sets host of messages (default: os.Hostname)
+func GELFHost(host string) GELFOption {
+	return func(f *gelfFormatter) {
+		f.host = host
+	}
+}
+
+// NewGELFFormatter returns new graylog extended log format 1.1 formatter, full message keeps source and stack data,
+// level is written as syslog severity and data keys as additional fields
+func NewGELFFormatter(options ...GELFOption) Formatter {
+	host, _ := os.Hostname()
+	f := &gelfFormatter{host: host}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+type gelfFormatter struct {
+	host string
+}
+
+func (f gelfFormatter) Format(entry Entry) string {
+	return formatObject(entry, f.object)
+}
+
+func (f gelfFormatter) object(entry Entry) *jsonObject {
+	message := strings.TrimSpace(entry.Message)
+	full := []string{message}
+	if entry.Source != "" {
+		full = append(full, entry.Source)
+	}
+	if stack, ok := entry.Data[dataStack]; ok {
+		full = append(full, fmt.Sprint(stack))
+	}
+	obj := &jsonObject{}
+	obj.add("version", gelfVersion)
+	obj.add("host", f.host)
+	obj.add("short_message", strings.SplitN(message, "\n", 2)[0])
+	obj.add("full_message", strings.Join(full, "\n"))
+	obj.add("timestamp", float64(entry.Raised.UnixNano()/1e6)/1e3)
+	obj.add("level", entry.Level.Severity())
+	extra := make(map[string]interface{}, len(entry.Data))
+	for key, value := range entry.Data {
+		if key == dataStack {
+			continue
+		}
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			extra[gelfField(key)] = value
+		default:
+			for _, f := range flattenValue(nil, key, value) {
+				extra[gelfField(f.key)] = f.value
+			}
+		}
+	}
+	addData(obj, extra)
+	return obj
+}
+
+// gelfField returns additional field name of key
+func gelfField(key string) string {
+	key = gelfFieldName.ReplaceAllString(key, "_")
+	if key == "id" {
+		key = "id_"
+	}
+	return "_" + key
+}
+
+// Compression type of message compression
+type Compression int
+
+const (
+	// CompressionGzip compresses messages with gzip
+	CompressionGzip Compression = iota
+
+	// CompressionZlib compresses messages with zlib
+	CompressionZlib
+
+	// CompressionNone does not compress messages
+	CompressionNone
+)
+
+// GELFWriterOption type of gelf writer option
+type GELFWriterOption func(*GELFWriter)
+
+// GELFWriterCompression sets compression of udp messages (default: CompressionGzip)
+func GELFWriterCompression(compression Compression) GELFWriterOption {
+	return func(w *GELFWriter) {
+		w.compression = compression
+	}
+}
+
+// GELFWriterChunkSize sets maximum size of udp chunks which must be greater than 12 bytes of chunk header (default: 1420)
+func GELFWriterChunkSize(size int) GELFWriterOption {
+	return func(w *GELFWriter) {
+		w.chunkSize = size
+	}
+}
+
+// GELFWriterTimeout sets timeout of dialing and writing (default: 5s)
+func GELFWriterTimeout(timeout time.Duration) GELFWriterOption {
+	return func(w *GELFWriter) {
+		w.timeout = timeout
+	}
+}
+
+// GELFWriter implements writer of gelf messages on graylog, udp messages are compressed and chunked
+// and tcp messages are framed with null byte and reconnected on failure
+type GELFWriter struct {
+	network     string
+	address     string
+	compression Compression
+	chunkSize   int
+	timeout     time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewGELFWriter returns new gelf writer connected on udp or tcp network and address
+func NewGELFWriter(network, address string, options ...GELFWriterOption) (*GELFWriter, error) {
+	w := &GELFWriter{
+		network:   network,
+		address:   address,
+		chunkSize: gelfChunkSize,
+		timeout:   5 * time.Second,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	if w.chunkSize <= gelfChunkHeader {
+		return nil, ErrGELFChunkSize
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes gelf message on graylog, it reconnects when connection is closed
+func (w *GELFWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if strings.HasPrefix(w.network, "udp") {
+		if w.conn == nil {
+			if err := w.connect(); err != nil {
+				return 0, err
+			}
+		}
+		if err := w.writeUDP(msg); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	frame := append(append(make([]byte, 0, len(msg)+1), msg...), 0)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+		if err = w.write(frame); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// Close closes connection of graylog
+func (w *GELFWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *GELFWriter) connect() (err error) {
+	w.conn, err = net.DialTimeout(w.network, w.address, w.timeout)
+	return err
+}
+
+func (w *GELFWriter) write(p []byte) error {
+	if w.timeout > 0 {
+		_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	_, err := w.conn.Write(p)
+	return err
+}
+
+func (w *GELFWriter) writeUDP(msg []byte) error {
+	msg, err := compress(msg, w.compression)
+	if err != nil {
+		return err
+	}
+	if len(msg) <= w.chunkSize {
+		return w.write(msg)
+	}
+	size := w.chunkSize - gelfChunkHeader
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return ErrGELFTooLarge
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk := append([]byte{0x1e, 0x0f}, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		if err := w.write(append(chunk, msg[i*size:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compress(p []byte, compression Compression) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	var err error
+	switch compression {
+	case CompressionGzip:
+		writer := gzip.NewWriter(buf)
+		if _, err = writer.Write(p); err == nil {
+			err = writer.Close()
+		}
+	case CompressionZlib:
+		writer := zlib.NewWriter(buf)
+		if _, err = writer.Write(p); err == nil {
+			err = writer.Close()
+		}
+	default:
+		return p, nil
+	}
+	return buf.Bytes(), err
+}
@@ -0,0 +1,212 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewGELFFormatter(t *testing.T) {
+	t.Run("must returns gelf formatter with host", func(t *testing.T) {
+		assert.Equal(t, NewGELFFormatter(GELFHost("host")), &gelfFormatter{host: "host"})
+	})
+}
+
+func Test_gelfFormatter_Format(t *testing.T) {
+	t.Run("must returns gelf document", func(t *testing.T) {
+		entry := Entry{
+			Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC),
+			Level:   LevelError,
+			Source:  "at main.run in /app/main.go:42",
+			Message: "can not connect\nsecond line",
+			Data: map[string]interface{}{
+				"stack":   "goroutine 1",
+				"id":      "abc",
+				"attempt": 3,
+				"db":      Namespace{"host": "localhost", "port": 5432},
+				"a key":   "value",
+			},
+		}
+		assert.JSONEq(t, `{
+			"version": "1.1",
+			"host": "host",
+			"short_message": "can not connect",
+			"full_message": "can not connect\nsecond line\nat main.run in /app/main.go:42\ngoroutine 1",
+			"timestamp": 1586521845.123,
+			"level": 3,
+			"_id_": "abc",
+			"_attempt": 3,
+			"_db.host": "localhost",
+			"_db.port": "5432",
+			"_a_key": "value"
+		}`, NewGELFFormatter(GELFHost("host")).Format(entry))
+	})
+}
+
+// startTestGELFServer reads udp chunks of conn and sends reassembled messages on channel
+func startTestGELFServer(conn net.PacketConn) <-chan []byte {
+	messages := make(chan []byte, 10)
+	go func() {
+		chunks := make(map[string][][]byte)
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			packet := append([]byte(nil), buf[:n]...)
+			if !bytes.HasPrefix(packet, []byte{0x1e, 0x0f}) {
+				messages <- packet
+				continue
+			}
+			id, seq, count := string(packet[2:10]), packet[10], packet[11]
+			if chunks[id] == nil {
+				chunks[id] = make([][]byte, count)
+			}
+			chunks[id][seq] = packet[12:]
+			complete := true
+			for _, chunk := range chunks[id] {
+				complete = complete && chunk != nil
+			}
+			if complete {
+				messages <- bytes.Join(chunks[id], nil)
+				delete(chunks, id)
+			}
+		}
+	}()
+	return messages
+}
+
+func TestGELFWriter_Write(t *testing.T) {
+	msg := `{"version":"1.1","host":"host","short_message":"` + strings.Repeat("a", 5000) + `"}`
+	receive := func(messages <-chan []byte) []byte {
+		select {
+		case msg := <-messages:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatal("message is not received")
+			return nil
+		}
+	}
+	tests := []struct {
+		name        string
+		compression Compression
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{
+			name:        "must writes gzip chunks on udp",
+			compression: CompressionGzip,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:        "must writes zlib chunks on udp",
+			compression: CompressionZlib,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zlib.NewReader(r)
+			},
+		},
+		{
+			name:        "must writes uncompressed chunks on udp",
+			compression: CompressionNone,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return r, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			defer conn.Close()
+			messages := startTestGELFServer(conn)
+			w, err := NewGELFWriter("udp", conn.LocalAddr().String(), GELFWriterCompression(tt.compression), GELFWriterChunkSize(512))
+			assert.NoError(t, err)
+			defer w.Close()
+			n, err := w.Write([]byte(msg + "\n"))
+			assert.NoError(t, err)
+			assert.Equal(t, len(msg)+1, n)
+			reader, err := tt.decompress(bytes.NewReader(receive(messages)))
+			assert.NoError(t, err)
+			got, err := ioutil.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, string(got))
+		})
+	}
+	t.Run("must returns error of too many chunks", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer conn.Close()
+		w, err := NewGELFWriter("udp", conn.LocalAddr().String(), GELFWriterCompression(CompressionNone), GELFWriterChunkSize(20))
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg))
+		assert.Equal(t, ErrGELFTooLarge, err)
+	})
+	t.Run("must writes chunks on udp4 and reconnects after close", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer conn.Close()
+		messages := startTestGELFServer(conn)
+		w, err := NewGELFWriter("udp4", conn.LocalAddr().String(), GELFWriterCompression(CompressionNone), GELFWriterChunkSize(512))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		defer w.Close()
+		assert.Equal(t, msg, string(receive(messages)))
+	})
+	t.Run("must writes null byte frames on tcp and reconnects", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+		messages := make(chan []byte, 10)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func(conn net.Conn) {
+					defer conn.Close()
+					reader := bufio.NewReader(conn)
+					for {
+						frame, err := reader.ReadBytes(0)
+						if err != nil {
+							return
+						}
+						messages <- frame[:len(frame)-1]
+					}
+				}(conn)
+			}
+		}()
+		w, err := NewGELFWriter("tcp", listener.Addr().String())
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, string(receive(messages)))
+		_ = w.conn.Close()
+		_, err = w.Write([]byte("{}\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "{}", string(receive(messages)))
+	})
+}
+
+func TestNewGELFWriter(t *testing.T) {
+	t.Run("must returns error of chunk size without room for data", func(t *testing.T) {
+		for _, size := range []int{-1, 0, 12} {
+			_, err := NewGELFWriter("udp", "127.0.0.1:12201", GELFWriterChunkSize(size))
+			assert.Equal(t, ErrGELFChunkSize, err)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError raises when remote server responds with unsuccessful status
+type StatusError struct {
+	// Code keeps status code of response
+	Code int
+
+	// Body keeps body of response
+	Body string
+}
+
+// Error returns message of status error
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("log: remote responded with status %d: %s", err.Code, err.Body)
+}
+
+// httpRetry keeps retry policy of http requests
+type httpRetry struct {
+	retries int
+	min     time.Duration
+	max     time.Duration
+}
+
+// do sends request of newRequest and returns body of successful response, network failures,
+// 429 and 5xx responses are retried with backoff or delay of Retry-After header which is limited
+// to maximum delay until context of request is done
+func (r httpRetry) do(client *http.Client, newRequest func() (*http.Request, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		body, delay, err := post(client, req)
+		if err == nil {
+			return body, nil
+		}
+		if status, ok := err.(*StatusError); ok && !retryableStatus(status.Code) {
+			return nil, err
+		}
+		if attempt >= r.retries {
+			return nil, err
+		}
+		if delay <= 0 {
+			delay = backoff(attempt, r.min, r.max)
+		} else if delay > r.max {
+			delay = r.max
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, err
+		}
+	}
+}
+
+func post(client *http.Client, req *http.Request) ([]byte, time.Duration, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, retryAfter(res.Header.Get("Retry-After")), &StatusError{Code: res.StatusCode, Body: string(body)}
+	}
+	return body, 0, nil
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter returns delay of Retry-After header in seconds or http date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPRetry_do(t *testing.T) {
+	retry := httpRetry{retries: 2, min: time.Millisecond, max: time.Millisecond}
+	tests := []struct {
+		name     string
+		statuses []int
+		calls    uint64
+		wantErr  error
+	}{
+		{
+			name:     "must returns body of successful response",
+			statuses: []int{http.StatusOK},
+			calls:    1,
+		},
+		{
+			name:     "must retries too many requests and server errors",
+			statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusAccepted},
+			calls:    3,
+		},
+		{
+			name:     "must not retries client errors",
+			statuses: []int{http.StatusBadRequest},
+			calls:    1,
+			wantErr:  &StatusError{Code: http.StatusBadRequest, Body: "body"},
+		},
+		{
+			name:     "must returns error after retries",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			calls:    3,
+			wantErr:  &StatusError{Code: http.StatusBadGateway, Body: "body"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls uint64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := atomic.AddUint64(&calls, 1)
+				w.WriteHeader(tt.statuses[call-1])
+				_, _ = w.Write([]byte("body"))
+			}))
+			defer server.Close()
+			body, err := retry.do(server.Client(), func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, server.URL, nil)
+			})
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "body", string(body))
+			}
+			assert.Equal(t, tt.calls, atomic.LoadUint64(&calls))
+		})
+	}
+	t.Run("must limits delay of retry after header to maximum delay", func(t *testing.T) {
+		var calls uint64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddUint64(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "86400")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+		started := time.Now()
+		_, err := retry.do(server.Client(), func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet, server.URL, nil)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), atomic.LoadUint64(&calls))
+		assert.True(t, time.Since(started) < time.Second)
+	})
+}
+
+func Test_retryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), retryAfter(""))
+	assert.Equal(t, 3*time.Second, retryAfter("3"))
+	assert.Equal(t, time.Duration(0), retryAfter("invalid"))
+	delay := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, delay > 50*time.Second && delay <= time.Minute)
+}
@@ -0,0 +1,167 @@
+//go:build linux
+// +build linux
+
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalReserved keeps fields which are written by sink so data can not duplicate them
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// JournalOption type of journal sink option
+type JournalOption func(*JournalSink)
+
+// JournalSocket sets path of journal socket (default: /run/systemd/journal/socket)
+func JournalSocket(path string) JournalOption {
+	return func(s *JournalSink) {
+		s.socket = path
+	}
+}
+
+// JournalIdentifier sets SYSLOG_IDENTIFIER of entries (default: name of executable)
+func JournalIdentifier(id string) JournalOption {
+	return func(s *JournalSink) {
+		s.identifier = id
+	}
+}
+
+// JournalSink implements sink of systemd journal native protocol, level is sent as PRIORITY, message as MESSAGE,
+// source as CODE_FILE, CODE_LINE and CODE_FUNC and data keys as uppercase fields,
+// large entries are sent as file descriptor of unlinked temp file
+type JournalSink struct {
+	socket     string
+	identifier string
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// NewJournalSink returns new journal sink connected on journal socket
+func NewJournalSink(options ...JournalOption) (*JournalSink, error) {
+	s := &JournalSink{
+		socket:     journalSocket,
+		identifier: filepath.Base(os.Args[0]),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	if _, err := os.Stat(s.socket); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	s.addr = &net.UnixAddr{Name: s.socket, Net: "unixgram"}
+	return s, nil
+}
+
+// Send sends entry on journal
+func (s *JournalSink) Send(entry Entry) error {
+	payload := journalPayload(entry, s.identifier)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _, err := s.conn.WriteMsgUnix(payload, nil, s.addr)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return s.sendFile(payload)
+	}
+	return err
+}
+
+// Close closes connection of journal socket
+func (s *JournalSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.Close()
+}
+
+func (s *JournalSink) sendFile(payload []byte) error {
+	file, err := ioutil.TempFile("/dev/shm", "journal")
+	if err != nil {
+		if file, err = ioutil.TempFile("", "journal"); err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+	_ = os.Remove(file.Name())
+	if _, err := file.Write(payload); err != nil {
+		return err
+	}
+	_, _, err = s.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), s.addr)
+	return err
+}
+
+func journalPayload(entry Entry, identifier string) []byte {
+	var payload []byte
+	add := func(key, value string) {
+		if !strings.ContainsRune(value, '\n') {
+			payload = append(payload, key+"="+value+"\n"...)
+			return
+		}
+		payload = append(payload, key+"\n"...)
+		size := make([]byte, 8)
+		binary.LittleEndian.PutUint64(size, uint64(len(value)))
+		payload = append(payload, size...)
+		payload = append(payload, value+"\n"...)
+	}
+	add("MESSAGE", strings.TrimSpace(entry.Message))
+	add("PRIORITY", strconv.Itoa(entry.Level.Severity()))
+	if identifier != "" {
+		add("SYSLOG_IDENTIFIER", identifier)
+	}
+	if function, file, line := sourceLocation(entry.Source); file != "" {
+		add("CODE_FILE", file)
+		add("CODE_LINE", strconv.Itoa(line))
+		add("CODE_FUNC", function)
+	}
+	for _, field := range flattenData(entry.Data) {
+		add(journalField(field.key), field.value)
+	}
+	return payload
+}
+
+// journalField returns journal field name of key which has only uppercase letters, digits and underscores,
+// names which are invalid or reserved by sink are prefixed with FIELD_
+func journalField(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		name = "FIELD_" + strings.TrimLeft(name, "_")
+	} else if journalReserved[name] {
+		name = "FIELD_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
@@ -0,0 +1,162 @@
+//go:build linux
+// +build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startTestJournal listens on unix datagram socket and sends fields of received payloads on channel
+func startTestJournal(t *testing.T, path string) (*net.UnixConn, <-chan map[string]string) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+	payloads := make(chan map[string]string, 10)
+	go func() {
+		buf := make([]byte, 1<<20)
+		oob := make([]byte, 1024)
+		for {
+			n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+			if err != nil {
+				return
+			}
+			data := append([]byte(nil), buf[:n]...)
+			if oobn > 0 {
+				messages, _ := syscall.ParseSocketControlMessage(oob[:oobn])
+				fds, _ := syscall.ParseUnixRights(&messages[0])
+				file := os.NewFile(uintptr(fds[0]), "journal")
+				_, _ = file.Seek(0, 0)
+				data, _ = ioutil.ReadAll(file)
+				_ = file.Close()
+			}
+			payloads <- parseTestJournalPayload(data)
+		}
+	}()
+	return conn, payloads
+}
+
+func parseTestJournalPayload(data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		line := string(data[:end])
+		data = data[end+1:]
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			fields[line[:i]] = line[i+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[line] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournalSink_Send(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "socket")
+	conn, payloads := startTestJournal(t, path)
+	defer conn.Close()
+
+	sink, err := NewJournalSink(JournalSocket(path), JournalIdentifier("app"))
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	receive := func() map[string]string {
+		select {
+		case fields := <-payloads:
+			return fields
+		case <-time.After(2 * time.Second):
+			t.Fatal("payload is not received")
+			return nil
+		}
+	}
+	t.Run("must sends entry fields", func(t *testing.T) {
+		err := sink.Send(Entry{
+			Level:   LevelWarning,
+			Source:  "at main.run in /app/main.go:42",
+			Message: "text message",
+			Data: map[string]interface{}{
+				"db":        Namespace{"query": "select\n1"},
+				"requestId": "abc",
+				"_private":  1,
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"MESSAGE":           "text message",
+			"PRIORITY":          "4",
+			"SYSLOG_IDENTIFIER": "app",
+			"CODE_FILE":         "/app/main.go",
+			"CODE_LINE":         "42",
+			"CODE_FUNC":         "main.run",
+			"DB_QUERY":          "select\n1",
+			"REQUESTID":         "abc",
+			"FIELD_PRIVATE":     "1",
+		}, receive())
+	})
+	t.Run("must prefixes data fields which are reserved by sink", func(t *testing.T) {
+		err := sink.Send(Entry{
+			Level:   LevelError,
+			Message: "text message",
+			Data: map[string]interface{}{
+				"message":           "data message",
+				"priority":          "high",
+				"syslog_identifier": "other",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"MESSAGE":                 "text message",
+			"PRIORITY":                "3",
+			"SYSLOG_IDENTIFIER":       "app",
+			"FIELD_MESSAGE":           "data message",
+			"FIELD_PRIORITY":          "high",
+			"FIELD_SYSLOG_IDENTIFIER": "other",
+		}, receive())
+	})
+	t.Run("must sends large entry as file descriptor", func(t *testing.T) {
+		message := strings.Repeat("a", 4<<20)
+		assert.NoError(t, sink.Send(Entry{Level: LevelInfo, Message: message}))
+		fields := receive()
+		assert.Equal(t, message, fields["MESSAGE"])
+		assert.Equal(t, "6", fields["PRIORITY"])
+	})
+}
+
+func TestNewJournalSink(t *testing.T) {
+	t.Run("must returns error when socket is not available", func(t *testing.T) {
+		_, err := NewJournalSink(JournalSocket(filepath.Join(os.TempDir(), "missing-journal.sock")))
+		assert.Error(t, err)
+	})
+}
+
+func Test_journalField(t *testing.T) {
+	tests := map[string]string{
+		"key":       "KEY",
+		"db.query":  "DB_QUERY",
+		"_key":      "FIELD_KEY",
+		"1key":      "FIELD_1KEY",
+		"":          "FIELD_",
+		"a-b c":     "A_B_C",
+		"message":   "FIELD_MESSAGE",
+		"code.file": "FIELD_CODE_FILE",
+	}
+	for key, want := range tests {
+		t.Run("must returns field name of "+key, func(t *testing.T) {
+			assert.Equal(t, want, journalField(key))
+		})
+	}
+}
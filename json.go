@@ -0,0 +1,176 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// TimeEncoding type of entry raised time encoding
+type TimeEncoding int
+
+const (
+	// TimeRFC3339Nano encodes time as RFC3339 string with nanoseconds
+	TimeRFC3339Nano TimeEncoding = iota
+
+	// TimeUnix encodes time as unix seconds with fraction
+	TimeUnix
+
+	// TimeUnixMilli encodes time as unix milliseconds
+	TimeUnixMilli
+)
+
+// JSONKeys keeps key names of json formatter, empty names keep defaults
+type JSONKeys struct {
+	// Time keeps key of raised time (default: Raised)
+	Time string
+
+	// Level keeps key of level (default: Level)
+	Level string
+
+	// Source keeps key of source (default: Source)
+	Source string
+
+	// Message keeps key of message (default: Message)
+	Message string
+
+	// Data keeps key of data, inlined data uses it for conflicting keys (default: Data)
+	Data string
+}
+
+// JSONOption type of json formatter option
+type JSONOption func(*jsonFormatter)
+
+// JSONKeyNames renames keys of json formatter
+func JSONKeyNames(keys JSONKeys) JSONOption {
+	return func(f *jsonFormatter) {
+		f.keys = keys
+	}
+}
+
+// JSONLevelString encodes level as lowercase string instead of number
+func JSONLevelString() JSONOption {
+	return func(f *jsonFormatter) {
+		f.levelString = true
+	}
+}
+
+// JSONInlineData writes data keys at top level, keys which conflict with other keys are kept under data key
+func JSONInlineData() JSONOption {
+	return func(f *jsonFormatter) {
+		f.inlineData = true
+	}
+}
+
+// JSONTime sets encoding of raised time (default: TimeRFC3339Nano)
+func JSONTime(encoding TimeEncoding) JSONOption {
+	return func(f *jsonFormatter) {
+		f.time = encoding
+	}
+}
+
+// JSONPretty indents json for development
+func JSONPretty() JSONOption {
+	return func(f *jsonFormatter) {
+		f.pretty = true
+	}
+}
+
+func (encoding TimeEncoding) encode(entry Entry) interface{} {
+	switch encoding {
+	case TimeUnix:
+		return float64(entry.Raised.UnixNano()) / 1e9
+	case TimeUnixMilli:
+		return entry.Raised.UnixNano() / 1e6
+	default:
+		return entry.Raised
+	}
+}
+
+func keyOrDefault(key, def string) string {
+	if key == "" {
+		return def
+	}
+	return key
+}
+
+func (f jsonFormatter) format(entry Entry) ([]byte, error) {
+	var lvl interface{} = entry.Level
+	if f.levelString {
+		lvl = strings.ToLower(entry.Level.String())
+	}
+	dataKey := keyOrDefault(f.keys.Data, "Data")
+	obj := &jsonObject{}
+	obj.add(keyOrDefault(f.keys.Time, "Raised"), f.time.encode(entry))
+	obj.add(keyOrDefault(f.keys.Level, "Level"), lvl)
+	obj.add(keyOrDefault(f.keys.Source, "Source"), entry.Source)
+	obj.add(keyOrDefault(f.keys.Message, "Message"), entry.Message)
+	if !f.inlineData {
+		obj.add(dataKey, entry.Data)
+		return obj.marshal(f.pretty)
+	}
+
+	conflicts := make(map[string]interface{})
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if obj.has(key) || key == dataKey {
+			conflicts[key] = entry.Data[key]
+			continue
+		}
+		obj.add(key, entry.Data[key])
+	}
+	if len(conflicts) > 0 {
+		obj.add(dataKey, conflicts)
+	}
+	return obj.marshal(f.pretty)
+}
+
+// jsonObject keeps ordered keys and values of json object
+type jsonObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (obj *jsonObject) add(key string, value interface{}) {
+	obj.keys = append(obj.keys, key)
+	obj.values = append(obj.values, value)
+}
+
+func (obj *jsonObject) has(key string) bool {
+	for _, k := range obj.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *jsonObject) marshal(pretty bool) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, key := range obj.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(obj.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	if !pretty {
+		return buf.Bytes(), nil
+	}
+	indented := new(bytes.Buffer)
+	err := json.Indent(indented, buf.Bytes(), "", "  ")
+	return indented.Bytes(), err
+}
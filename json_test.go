@@ -0,0 +1,71 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_jsonFormatter_Format_options(t *testing.T) {
+	entry := Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC),
+		Level:   LevelWarning,
+		Source:  "at test in test.go:10",
+		Message: "text message",
+		Data: map[string]interface{}{
+			"key": "value",
+			"msg": "conflict",
+		},
+	}
+	keys := JSONKeys{Time: "ts", Level: "level", Source: "caller", Message: "msg", Data: "fields"}
+	tests := []struct {
+		name    string
+		options []JSONOption
+		want    string
+	}{
+		{
+			name:    "must returns renamed keys",
+			options: []JSONOption{JSONKeyNames(keys)},
+			want:    `{"ts":"2020-04-10T12:30:45.123456789Z","level":2,"caller":"at test in test.go:10","msg":"text message","fields":{"key":"value","msg":"conflict"}}`,
+		},
+		{
+			name:    "must returns level as string",
+			options: []JSONOption{JSONKeyNames(JSONKeys{Data: "-"}), JSONLevelString()},
+			want:    `{"Raised":"2020-04-10T12:30:45.123456789Z","Level":"warning","Source":"at test in test.go:10","Message":"text message","-":{"key":"value","msg":"conflict"}}`,
+		},
+		{
+			name:    "must returns inlined data with conflicts under data key",
+			options: []JSONOption{JSONKeyNames(keys), JSONInlineData()},
+			want:    `{"ts":"2020-04-10T12:30:45.123456789Z","level":2,"caller":"at test in test.go:10","msg":"text message","key":"value","fields":{"msg":"conflict"}}`,
+		},
+		{
+			name:    "must returns time as unix seconds",
+			options: []JSONOption{JSONKeyNames(JSONKeys{Time: "ts"}), JSONTime(TimeUnix)},
+			want:    `{"ts":1586521845.1234567,"Level":2,"Source":"at test in test.go:10","Message":"text message","Data":{"key":"value","msg":"conflict"}}`,
+		},
+		{
+			name:    "must returns time as unix milliseconds",
+			options: []JSONOption{JSONTime(TimeUnixMilli)},
+			want:    `{"Raised":1586521845123,"Level":2,"Source":"at test in test.go:10","Message":"text message","Data":{"key":"value","msg":"conflict"}}`,
+		},
+		{
+			name:    "must returns indented json",
+			options: []JSONOption{JSONKeyNames(keys), JSONInlineData(), JSONPretty()},
+			want: `{
+  "ts": "2020-04-10T12:30:45.123456789Z",
+  "level": 2,
+  "caller": "at test in test.go:10",
+  "msg": "text message",
+  "key": "value",
+  "fields": {
+    "msg": "conflict"
+  }
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewJSONFormatter(tt.options...).Format(entry))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package log
+
+import "fmt"
+
+// Lazy type of value function which evaluates only when entry logs
+type Lazy func() interface{}
+
+func (fn Lazy) resolve() (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("lazy value panicked: %v", r)
+		}
+	}()
+	return fn()
+}
+
+func resolveLazy(data map[string]interface{}) map[string]interface{} {
+	for key, value := range data {
+		switch value := value.(type) {
+		case Lazy:
+			data[key] = value.resolve()
+		case Namespace:
+			resolveLazy(value)
+		}
+	}
+	return data
+}
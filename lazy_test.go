@@ -0,0 +1,63 @@
+package log
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	resetTest()
+	t.Run("must not evaluates value when level is filtered", func(t *testing.T) {
+		SetLevel(LevelError)
+		defer SetLevel(LevelDebug)
+		calls := 0
+		Value("key", Lazy(func() interface{} {
+			calls++
+			return "value"
+		})).Info("text message")
+		assert.Equal(t, 0, calls)
+		assert.Empty(t, testOutput.String())
+	})
+	t.Run("must evaluates value once when entry logs", func(t *testing.T) {
+		calls := 0
+		Value("key", Lazy(func() interface{} {
+			calls++
+			return "lazy value"
+		})).Info("text message")
+		assert.Equal(t, 1, calls)
+		assert.Contains(t, testOutput.String(), "\"key\":\"lazy value\"")
+		testOutput.Reset()
+	})
+	t.Run("must reports panic of value as field", func(t *testing.T) {
+		Value("key", Lazy(func() interface{} {
+			panic("boom")
+		})).Info("text message")
+		assert.Contains(t, testOutput.String(), "\"key\":\"lazy value panicked: boom\"")
+		testOutput.Reset()
+	})
+}
+
+func TestLazy_resolve(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   Lazy
+		want interface{}
+	}{
+		{
+			name: "must returns value of function",
+			fn:   func() interface{} { return 10 },
+			want: 10,
+		},
+		{
+			name: "must returns panic report of function",
+			fn:   func() interface{} { panic(fmt.Errorf("failed")) },
+			want: "lazy value panicked: failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.fn.resolve())
+		})
+	}
+}
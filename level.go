@@ -37,3 +37,22 @@ func (lvl Level) String() string {
 		return "Unknown"
 	}
 }
+
+// Severity returns syslog severity of level, debug is 7 (debug), info is 6 (informational),
+// warning is 4 (warning), error is 3 (error), fatal is 2 (critical) and unknown is 5 (notice)
+func (lvl Level) Severity() int {
+	switch lvl {
+	case LevelDebug:
+		return 7
+	case LevelInfo:
+		return 6
+	case LevelWarning:
+		return 4
+	case LevelError:
+		return 3
+	case LevelFatal:
+		return 2
+	default:
+		return 5
+	}
+}
@@ -48,3 +48,23 @@ func TestLevel_String(t *testing.T) {
 		})
 	}
 }
+
+func TestLevel_Severity(t *testing.T) {
+	tests := []struct {
+		name string
+		lvl  Level
+		want int
+	}{
+		{name: "must returns debug severity", lvl: LevelDebug, want: 7},
+		{name: "must returns informational severity", lvl: LevelInfo, want: 6},
+		{name: "must returns warning severity", lvl: LevelWarning, want: 4},
+		{name: "must returns error severity", lvl: LevelError, want: 3},
+		{name: "must returns critical severity", lvl: LevelFatal, want: 2},
+		{name: "must returns notice severity", lvl: 10, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.lvl.Severity())
+		})
+	}
+}
@@ -1,21 +1,25 @@
 package log
 
 import (
+	"context"
 	"io"
 	"os"
 )
 
 var (
-	output    io.Writer = os.Stdout
-	level               = LevelInfo
-	exit                = os.Exit
-	formatter           = NewTextFormatter()
-	constants           = make(map[string]interface{})
+	output         io.Writer = os.Stdout
+	outputTerminal           = isTerminal(os.Stdout)
+	level                    = LevelInfo
+	exit                     = os.Exit
+	formatter                = NewTextFormatter()
+	constants                = make(map[string]interface{})
+	collision                = CollisionReplace
 )
 
 // SetOutput sets logging output
 func SetOutput(w io.Writer) {
 	output = w
+	outputTerminal = isTerminal(w)
 }
 
 // SetLevel sets logging minimum level
@@ -33,29 +37,35 @@ func SetConstant(key string, value interface{}) {
 	constants[key] = value
 }
 
+// SetCollision sets policy of data which collides with constants
+func SetCollision(c Collision) {
+	collision = c
+}
+
 // Debug creates entry with message and logs in debug level
 func Debug(message string) {
-	NewEntry().Debug(message)
+	NewEntry().log(LevelDebug, message)
 }
 
 // Info creates entry with message and logs in info level
 func Info(message string) {
-	NewEntry().Info(message)
+	NewEntry().log(LevelInfo, message)
 }
 
 // Warning creates entry with message and logs in warning level
 func Warning(message string) {
-	NewEntry().Warning(message)
+	NewEntry().log(LevelWarning, message)
 }
 
 // Error creates entry with message and logs in error level
 func Error(message string) {
-	NewEntry().Error(message)
+	NewEntry().log(LevelError, message)
 }
 
-// Fatal creates entry with message and logs in fatal level so exit with code 1
+// Fatal creates entry with message and logs in fatal level so runs exit handlers and exits with exit code
 func Fatal(message string) {
-	NewEntry().Fatal(message)
+	NewEntry().log(LevelFatal, message)
+	Exit(exitCode)
 }
 
 // With creates entry with data and returns that
@@ -67,3 +77,18 @@ func With(data interface{}) *Entry {
 func Value(key string, value interface{}) *Entry {
 	return NewEntry().Value(key, value)
 }
+
+// Group creates entry which nests next data under name and returns that
+func Group(name string) *Entry {
+	return NewEntry().Group(name)
+}
+
+// Trace creates entry with trace context and returns that
+func Trace(tc TraceContext) *Entry {
+	return NewEntry().Trace(tc)
+}
+
+// Context creates entry with trace context which is carried by context and returns that
+func Context(ctx context.Context) *Entry {
+	return NewEntry().Context(ctx)
+}
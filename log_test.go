@@ -199,3 +199,44 @@ func TestWith(t *testing.T) {
 		assert.NotNil(t, entry)
 	})
 }
+
+func TestGroup(t *testing.T) {
+	resetTest()
+	t.Run("must returns entry with grouped value", func(t *testing.T) {
+		entry := Group("db").Value("query", "select")
+		assert.Equal(t, Namespace{"query": "select"}, entry.Fields()["db"])
+	})
+}
+
+func TestSetCollision(t *testing.T) {
+	resetTest()
+	SetConstant("service", "api")
+	tests := []struct {
+		name      string
+		collision Collision
+		want      map[string]interface{}
+	}{
+		{
+			name:      "must replaces constant with value",
+			collision: CollisionReplace,
+			want:      map[string]interface{}{"service": "db"},
+		},
+		{
+			name:      "must keeps constant and drops value",
+			collision: CollisionKeep,
+			want:      map[string]interface{}{"service": "api"},
+		},
+		{
+			name:      "must keeps constant and renames value",
+			collision: CollisionRename,
+			want:      map[string]interface{}{"service": "api", dataFields: Namespace{"service": "db"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetCollision(tt.collision)
+			assert.Equal(t, tt.want, Value("service", "db").Fields())
+			assert.Equal(t, Namespace{"service": "db"}, Group("db").Value("service", "db").Fields()["db"])
+		})
+	}
+}
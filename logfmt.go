@@ -0,0 +1,109 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrLogfmt raises when logfmt line can not parse
+var ErrLogfmt = errors.New("log: invalid logfmt line")
+
+// NewLogfmtFormatter returns new logfmt formatter
+func NewLogfmtFormatter() Formatter {
+	return new(logfmtFormatter)
+}
+
+type logfmtFormatter struct {
+}
+
+// Format returns logfmt line of entry, data keys which collide with written keys are prefixed with fields
+func (logfmtFormatter) Format(entry Entry) string {
+	builder := new(strings.Builder)
+	writeLogfmt(builder, "time", entry.Raised.Format(time.RFC3339Nano))
+	writeLogfmt(builder, "level", strings.ToLower(entry.Level.String()))
+	writeLogfmt(builder, "msg", strings.TrimSpace(entry.Message))
+	writeLogfmt(builder, "caller", entry.Source)
+	written := map[string]bool{"time": true, "level": true, "msg": true, "caller": true}
+	for _, f := range flattenData(entry.Data) {
+		key := logfmtKey(f.key)
+		for written[key] {
+			key = dataFields + "." + key
+		}
+		written[key] = true
+		writeLogfmt(builder, key, f.value)
+	}
+	return builder.String()
+}
+
+// logfmtKey returns key with spaces, equal signs, quotes and unprintable characters replaced by underscore
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func writeLogfmt(builder *strings.Builder, key, value string) {
+	if builder.Len() > 0 {
+		builder.WriteByte(' ')
+	}
+	builder.WriteString(logfmtKey(key))
+	builder.WriteByte('=')
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r)
+	}) >= 0 {
+		value = strconv.Quote(value)
+	}
+	builder.WriteString(value)
+}
+
+// ParseLogfmt returns keys and values of logfmt line
+func ParseLogfmt(line string) (map[string]string, error) {
+	values := make(map[string]string)
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimLeft(line, " ") {
+		end := strings.IndexAny(line, "= ")
+		if end < 0 {
+			end = len(line)
+		}
+		key := line[:end]
+		if key == "" {
+			return nil, ErrLogfmt
+		}
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			values[key] = ""
+			continue
+		}
+		line = line[1:]
+		if strings.HasPrefix(line, "\"") {
+			end = 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, ErrLogfmt
+			}
+			value, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, ErrLogfmt
+			}
+			values[key] = value
+			line = line[end+1:]
+			continue
+		}
+		if end = strings.IndexByte(line, ' '); end < 0 {
+			end = len(line)
+		}
+		values[key] = line[:end]
+		line = line[end:]
+	}
+	return values, nil
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewLogfmtFormatter(t *testing.T) {
+	t.Run("must returns logfmt formatter", func(t *testing.T) {
+		assert.Equal(t, NewLogfmtFormatter(), new(logfmtFormatter))
+	})
+}
+
+func Test_logfmtFormatter_Format(t *testing.T) {
+	formatter := new(logfmtFormatter)
+	raised := time.Date(2020, 4, 10, 12, 30, 45, 123000000, time.UTC)
+	tests := []struct {
+		name string
+		arg  Entry
+		want string
+	}{
+		{
+			name: "must returns line with ordered keys",
+			arg: Entry{
+				Raised:  raised,
+				Level:   LevelWarning,
+				Source:  "at test in test.go:10",
+				Message: "text message",
+				Data: map[string]interface{}{
+					"key":   "value",
+					"error": "can not do job",
+				},
+			},
+			want: `time=2020-04-10T12:30:45.123Z level=warning msg="text message" caller="at test in test.go:10" error="can not do job" key=value`,
+		},
+		{
+			name: "must returns line with escaped values",
+			arg: Entry{
+				Raised:  raised,
+				Level:   LevelInfo,
+				Message: "say \"hi\"\n",
+				Data: map[string]interface{}{
+					"my key": "a=b",
+					"empty":  "",
+					"path":   `c:\dir`,
+				},
+			},
+			want: `time=2020-04-10T12:30:45.123Z level=info msg="say \"hi\"" caller="" empty="" my_key="a=b" path="c:\\dir"`,
+		},
+		{
+			name: "must returns line with flattened data",
+			arg: Entry{
+				Raised: raised,
+				Level:  LevelDebug,
+				Data: map[string]interface{}{
+					"db":   Namespace{"query": "select 1", "rows": 2},
+					"user": struct{ Name string }{Name: "john"},
+					"ids":  []int{1, 2},
+				},
+			},
+			want: `time=2020-04-10T12:30:45.123Z level=debug msg="" caller="" db.query="select 1" db.rows=2 ids=[1,2] user.Name=john`,
+		},
+		{
+			name: "must returns line with prefixed colliding keys",
+			arg: Entry{
+				Raised:  raised,
+				Level:   LevelInfo,
+				Message: "real",
+				Data: map[string]interface{}{
+					"level":  "x",
+					"msg":    "fake",
+					"fields": Namespace{"msg": "nested"},
+				},
+			},
+			want: `time=2020-04-10T12:30:45.123Z level=info msg=real caller="" fields.msg=nested fields.level=x fields.fields.msg=fake`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatter.Format(tt.arg))
+		})
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "must returns keys and values",
+			line: `level=info msg="text \"message\"" flag empty="" path="c:\\dir"`,
+			want: map[string]string{"level": "info", "msg": `text "message"`, "flag": "", "empty": "", "path": `c:\dir`},
+		},
+		{
+			name:    "must returns error with unterminated quote",
+			line:    `msg="text`,
+			wantErr: true,
+		},
+		{
+			name:    "must returns error with empty key",
+			line:    `=value`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogfmt(tt.line)
+			if tt.wantErr {
+				assert.Equal(t, ErrLogfmt, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("must round trips formatted entry", func(t *testing.T) {
+		entry := Entry{
+			Raised:  time.Now(),
+			Level:   LevelError,
+			Source:  "at test in test.go:10",
+			Message: "multi\nline \"message\" = \\ ü",
+			Data: map[string]interface{}{
+				"db":    Namespace{"query": "select * from t where a = 'b'"},
+				"count": 10,
+			},
+		}
+		got, err := ParseLogfmt(new(logfmtFormatter).Format(entry))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"time":     entry.Raised.Format(time.RFC3339Nano),
+			"level":    "error",
+			"msg":      entry.Message,
+			"caller":   entry.Source,
+			"db.query": "select * from t where a = 'b'",
+			"count":    "10",
+		}, got)
+	})
+	t.Run("must round trips formatted entry with colliding keys", func(t *testing.T) {
+		entry := Entry{
+			Raised:  time.Now(),
+			Level:   LevelInfo,
+			Message: "real",
+			Data: map[string]interface{}{
+				"time":   "yesterday",
+				"level":  "x",
+				"msg":    "fake",
+				"caller": "elsewhere",
+			},
+		}
+		got, err := ParseLogfmt(new(logfmtFormatter).Format(entry))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"time":          entry.Raised.Format(time.RFC3339Nano),
+			"level":         "info",
+			"msg":           "real",
+			"caller":        "",
+			"fields.time":   "yesterday",
+			"fields.level":  "x",
+			"fields.msg":    "fake",
+			"fields.caller": "elsewhere",
+		}, got)
+	})
+}
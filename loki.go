@@ -0,0 +1,210 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const lokiLevelLabel = "level"
+
+// LokiOption type of loki sink option
+type LokiOption func(*LokiSink)
+
+// LokiLabels sets data or constant keys which are sent as stream labels, level key sends level of entry
+// (default: level)
+func LokiLabels(keys ...string) LokiOption {
+	return func(s *LokiSink) {
+		s.labels = keys
+	}
+}
+
+// LokiStaticLabels sets labels of all streams
+func LokiStaticLabels(labels map[string]string) LokiOption {
+	return func(s *LokiSink) {
+		s.static = labels
+	}
+}
+
+// LokiFormatter sets formatter of lines (default: logfmt formatter)
+func LokiFormatter(f Formatter) LokiOption {
+	return func(s *LokiSink) {
+		s.formatter = f
+	}
+}
+
+// LokiTenant sets tenant id which is sent in X-Scope-OrgID header
+func LokiTenant(id string) LokiOption {
+	return func(s *LokiSink) {
+		s.tenant = id
+	}
+}
+
+// LokiBatch sets maximum size and wait duration of batches (default: 1000, 1s)
+func LokiBatch(size int, wait time.Duration) LokiOption {
+	return func(s *LokiSink) {
+		s.size = size
+		s.wait = wait
+	}
+}
+
+// LokiBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 10000)
+func LokiBuffer(capacity int) LokiOption {
+	return func(s *LokiSink) {
+		s.capacity = capacity
+	}
+}
+
+// LokiRetry sets retries of failed pushes and their backoff delays (default: 5, 500ms, 30s)
+func LokiRetry(retries int, min, max time.Duration) LokiOption {
+	return func(s *LokiSink) {
+		s.retry = httpRetry{retries: retries, min: min, max: max}
+	}
+}
+
+// LokiClient sets http client of pushes (default: client with 10s timeout)
+func LokiClient(client *http.Client) LokiOption {
+	return func(s *LokiSink) {
+		s.client = client
+	}
+}
+
+// LokiSink implements sink of grafana loki push api, entries are batched into streams of labels
+// and pushes are retried on 429 and 5xx responses, streams without any label are labeled with level
+// because loki rejects them
+type LokiSink struct {
+	url       string
+	labels    []string
+	static    map[string]string
+	formatter Formatter
+	tenant    string
+	size      int
+	wait      time.Duration
+	capacity  int
+	retry     httpRetry
+	client    *http.Client
+
+	batcher *batcher
+}
+
+// NewLokiSink returns new loki sink which pushes on loki server of url
+func NewLokiSink(url string, options ...LokiOption) *LokiSink {
+	s := &LokiSink{
+		url:       strings.TrimRight(url, "/") + "/loki/api/v1/push",
+		labels:    []string{lokiLevelLabel},
+		formatter: NewLogfmtFormatter(),
+		size:      1000,
+		wait:      time.Second,
+		capacity:  10000,
+		retry:     httpRetry{retries: 5, min: 500 * time.Millisecond, max: 30 * time.Second},
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.formatter = sinkFormatter(s.formatter)
+	s.batcher = newBatcher(s.size, s.wait, s.capacity, s.flush)
+	return s
+}
+
+// Send queues entry to push in next batch
+func (s *LokiSink) Send(entry Entry) error {
+	return s.batcher.add(entry)
+}
+
+// Flush pushes queued entries, it returns last error of failed entries since previous flush
+func (s *LokiSink) Flush() error {
+	return s.batcher.sync()
+}
+
+// Close pushes queued entries and stops sink, it returns last error of failed entries since previous flush
+func (s *LokiSink) Close() error {
+	return s.batcher.close()
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (s *LokiSink) flush(entries []Entry) error {
+	streams := make(map[string]*lokiStream)
+	var keys []string
+	for _, entry := range entries {
+		labels := s.streamLabels(entry)
+		key := fmt.Sprint(labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(entry.Raised.UnixNano(), 10),
+			s.formatter.Format(entry),
+		})
+	}
+	sort.Strings(keys)
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range keys {
+		push.Streams = append(push.Streams, streams[key])
+	}
+	body, err := json.Marshal(push)
+	if err == nil {
+		_, err = s.retry.do(s.client, func() (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if s.tenant != "" {
+				req.Header.Set("X-Scope-OrgID", s.tenant)
+			}
+			return req, nil
+		})
+	}
+	if err != nil {
+		for _, entry := range entries {
+			sendFailed(entry, err)
+		}
+	}
+	return err
+}
+
+func (s *LokiSink) streamLabels(entry Entry) map[string]string {
+	labels := make(map[string]string, len(s.static)+len(s.labels))
+	for key, value := range s.static {
+		labels[key] = value
+	}
+	for _, key := range s.labels {
+		if key == lokiLevelLabel {
+			labels[key] = strings.ToLower(entry.Level.String())
+			continue
+		}
+		if value, ok := entry.Data[key]; ok {
+			labels[lokiLabelName(key)] = fmt.Sprint(value)
+		}
+	}
+	if len(labels) == 0 {
+		labels[lokiLevelLabel] = strings.ToLower(entry.Level.String())
+	}
+	return labels
+}
+
+// lokiLabelName returns prometheus label name of key
+func lokiLabelName(key string) string {
+	name := []byte(key)
+	for i, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c != '_' && (c < '0' || c > '9' || i == 0) {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// startTestLokiServer decodes pushes, it responds with status of failures before accepting
+func startTestLokiServer(t *testing.T, failures ...int) (*httptest.Server, <-chan *http.Request, <-chan testLokiPush) {
+	requests := make(chan *http.Request, 10)
+	pushes := make(chan testLokiPush, 10)
+	var calls uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if call := int(atomic.AddUint64(&calls, 1)); call <= len(failures) {
+			w.WriteHeader(failures[call-1])
+			return
+		}
+		var push testLokiPush
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+		requests <- r
+		pushes <- push
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, requests, pushes
+}
+
+func TestLokiSink_Send(t *testing.T) {
+	raised := time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC)
+	entry := func(lvl Level, app string) Entry {
+		return Entry{
+			Raised:  raised,
+			Level:   lvl,
+			Message: "text message",
+			Data:    map[string]interface{}{"app": app, "key": "value"},
+		}
+	}
+	receive := func(pushes <-chan testLokiPush) testLokiPush {
+		select {
+		case push := <-pushes:
+			return push
+		case <-time.After(2 * time.Second):
+			t.Fatal("push is not received")
+			return testLokiPush{}
+		}
+	}
+	t.Run("must pushes batched entries grouped by stream labels", func(t *testing.T) {
+		server, requests, pushes := startTestLokiServer(t)
+		defer server.Close()
+		sink := NewLokiSink(server.URL+"/", LokiLabels("app", "level"), LokiStaticLabels(map[string]string{"env": "test"}),
+			LokiTenant("tenant"), LokiBatch(3, time.Hour), LokiFormatter(NewJSONFormatter()))
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry(LevelInfo, "api")))
+		assert.NoError(t, sink.Send(entry(LevelError, "api")))
+		assert.NoError(t, sink.Send(entry(LevelInfo, "api")))
+		push := receive(pushes)
+		req := <-requests
+		assert.Equal(t, "/loki/api/v1/push", req.URL.Path)
+		assert.Equal(t, "tenant", req.Header.Get("X-Scope-OrgID"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Len(t, push.Streams, 2)
+		assert.Equal(t, map[string]string{"app": "api", "level": "error", "env": "test"}, push.Streams[0].Stream)
+		assert.Len(t, push.Streams[0].Values, 1)
+		assert.Equal(t, map[string]string{"app": "api", "level": "info", "env": "test"}, push.Streams[1].Stream)
+		assert.Len(t, push.Streams[1].Values, 2)
+		assert.Equal(t, "1586521845123456789", push.Streams[1].Values[0][0])
+		assert.Equal(t, NewJSONFormatter().Format(entry(LevelInfo, "api")), push.Streams[1].Values[0][1])
+	})
+	t.Run("must labels streams with level by default and without labels", func(t *testing.T) {
+		server, _, pushes := startTestLokiServer(t)
+		defer server.Close()
+		for _, sink := range []*LokiSink{NewLokiSink(server.URL), NewLokiSink(server.URL, LokiLabels("missing"))} {
+			assert.NoError(t, sink.Send(entry(LevelWarning, "api")))
+			assert.NoError(t, sink.Close())
+			assert.Equal(t, map[string]string{"level": "warning"}, receive(pushes).Streams[0].Stream)
+		}
+	})
+	t.Run("must retries push on server errors", func(t *testing.T) {
+		server, _, pushes := startTestLokiServer(t, http.StatusTooManyRequests, http.StatusInternalServerError)
+		defer server.Close()
+		sink := NewLokiSink(server.URL, LokiRetry(2, time.Millisecond, time.Millisecond))
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry(LevelInfo, "api")))
+		assert.NoError(t, sink.Flush())
+		assert.Len(t, receive(pushes).Streams, 1)
+	})
+	t.Run("must reports failed entries on client errors", func(t *testing.T) {
+		resetTest()
+		server, _, _ := startTestLokiServer(t, http.StatusBadRequest)
+		defer server.Close()
+		sink := NewLokiSink(server.URL, LokiRetry(2, time.Millisecond, time.Millisecond))
+		before := GetStats()
+		assert.NoError(t, sink.Send(entry(LevelInfo, "api")))
+		assert.NoError(t, sink.Send(entry(LevelInfo, "api")))
+		err := sink.Close()
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*StatusError).Code)
+		assert.Equal(t, before.FailedSends+2, GetStats().FailedSends)
+	})
+}
+
+func Test_lokiLabelName(t *testing.T) {
+	assert.Equal(t, "service_name", lokiLabelName("service.name"))
+	assert.Equal(t, "_abc", lokiLabelName("1abc"))
+	assert.Equal(t, "a1_B", lokiLabelName("a1_B"))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"testing"
+	"time"
 )
 
 var (
@@ -19,8 +20,16 @@ func TestMain(m *testing.M) {
 func resetTest() {
 	testOutput.Reset()
 	level = LevelDebug
-	output = testOutput
+	SetOutput(testOutput)
 	exit = func(code int) {}
+	exitCode = 1
+	exitTimeout = time.Second
+	exitHandlers = nil
 	formatter = new(jsonFormatter)
 	constants = make(map[string]interface{})
+	collision = CollisionReplace
+	errorHandler = nil
+	fallback = nil
+	sinks = nil
+	traceKeys = defaultTraceKeys()
 }
@@ -0,0 +1,368 @@
+package log
+
+import (
+	"bufio"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// msgpackMaxLength limits lengths of decoded strings, binaries, extensions, arrays and maps
+	msgpackMaxLength = 1 << 20
+	// msgpackMaxDepth limits nesting of decoded arrays and maps
+	msgpackMaxDepth = 32
+)
+
+// errMsgpack raises when msgpack value can not decode
+var errMsgpack = errors.New("log: invalid msgpack value")
+
+// eventTime keeps time which encodes as fluent event time msgpack extension
+type eventTime time.Time
+
+// msgpackExt keeps decoded msgpack extension
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// appendMsgpack appends msgpack encoding of value to b, unsupported values are encoded as string
+// and pointers, maps and slices which refer to themselves are encoded as their address
+func appendMsgpack(b []byte, value interface{}) []byte {
+	return appendMsgpackVisited(b, value, make(map[uintptr]bool))
+}
+
+// appendMsgpackVisited appends msgpack encoding of value to b, visited keeps addresses of pointers,
+// maps and slices on path of value to detect cycles
+func appendMsgpackVisited(b []byte, value interface{}, visited map[uintptr]bool) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case string:
+		return appendMsgpackString(b, v)
+	case []byte:
+		return appendMsgpackBinary(b, v)
+	case eventTime:
+		t := time.Time(v)
+		b = append(b, 0xd7, 0x00)
+		b = appendUint32(b, uint32(t.Unix()))
+		return appendUint32(b, uint32(t.Nanosecond()))
+	case error:
+		return appendMsgpackString(b, v.Error())
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return appendMsgpackString(b, string(text))
+		}
+	case fmt.Stringer:
+		return appendMsgpackString(b, v.String())
+	}
+
+	ref := reflect.ValueOf(value)
+	switch ref.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if ref.IsNil() {
+			break
+		}
+		if visited[ref.Pointer()] {
+			return appendMsgpackString(b, fmt.Sprintf("%p", value))
+		}
+		visited[ref.Pointer()] = true
+		defer delete(visited, ref.Pointer())
+	}
+	switch ref.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if ref.IsNil() {
+			return append(b, 0xc0)
+		}
+		return appendMsgpackVisited(b, ref.Elem().Interface(), visited)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendMsgpackInt(b, ref.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendMsgpackUint(b, ref.Uint())
+	case reflect.Float32:
+		return appendUint32(append(b, 0xca), math.Float32bits(float32(ref.Float())))
+	case reflect.Float64:
+		return appendUint64(append(b, 0xcb), math.Float64bits(ref.Float()))
+	case reflect.String:
+		return appendMsgpackString(b, ref.String())
+	case reflect.Slice, reflect.Array:
+		b = appendMsgpackHeader(b, ref.Len(), 0x90, 0xdc, 0xdd)
+		for i := 0; i < ref.Len(); i++ {
+			b = appendMsgpackVisited(b, ref.Index(i).Interface(), visited)
+		}
+		return b
+	case reflect.Map:
+		b = appendMsgpackHeader(b, ref.Len(), 0x80, 0xde, 0xdf)
+		for _, key := range ref.MapKeys() {
+			b = appendMsgpackString(b, fmt.Sprint(key.Interface()))
+			b = appendMsgpackVisited(b, ref.MapIndex(key).Interface(), visited)
+		}
+		return b
+	case reflect.Struct:
+		refType := ref.Type()
+		var keys []string
+		var values []interface{}
+		for i := 0; i < refType.NumField(); i++ {
+			if refType.Field(i).PkgPath != "" {
+				continue
+			}
+			name := refType.Field(i).Name
+			if tag := strings.Split(refType.Field(i).Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			keys = append(keys, name)
+			values = append(values, ref.Field(i).Interface())
+		}
+		b = appendMsgpackHeader(b, len(keys), 0x80, 0xde, 0xdf)
+		for i, key := range keys {
+			b = appendMsgpackString(b, key)
+			b = appendMsgpackVisited(b, values[i], visited)
+		}
+		return b
+	}
+	return appendMsgpackString(b, fmt.Sprint(value))
+}
+
+func appendMsgpackInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return appendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return appendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return appendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(v))
+	default:
+		return appendUint64(append(b, 0xcf), v)
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackBinary(b []byte, p []byte) []byte {
+	switch n := len(p); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = appendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = appendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, p...)
+}
+
+// appendMsgpackHeader appends header of array or map with fix, 16 bits and 32 bits codes
+func appendMsgpackHeader(b []byte, n int, fix, code16, code32 byte) []byte {
+	switch {
+	case n <= 15:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, code16), uint16(n))
+	default:
+		return appendUint32(append(b, code32), uint32(n))
+	}
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+// decodeMsgpack decodes one msgpack value from reader, maps are decoded with string keys,
+// lengths and nesting are limited so invalid values can not exhaust memory
+func decodeMsgpack(r *bufio.Reader) (interface{}, error) {
+	return decodeMsgpackDepth(r, 0)
+}
+
+func decodeMsgpackDepth(r *bufio.Reader, depth int) (interface{}, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return readMsgpackString(r, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMsgpackArray(r, int(code&0x0f), depth)
+	case code&0xf0 == 0x80:
+		return readMsgpackMap(r, int(code&0x0f), depth)
+	}
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := readMsgpackUint(r, 1<<(code-0xcc))
+		return v, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		v, err := readMsgpackUint(r, size)
+		shift := uint(64 - size*8)
+		return int64(v<<shift) >> shift, err
+	case 0xca:
+		v, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(v), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(code-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(r, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(code-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(code-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(code-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n), depth)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(code-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackUint(r, 1<<(code-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, int(n))
+	}
+	return nil, errMsgpack
+}
+
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	p, err := readMsgpackBytes(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range p {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func readMsgpackBytes(r *bufio.Reader, n int) ([]byte, error) {
+	if n > msgpackMaxLength {
+		return nil, errMsgpack
+	}
+	p := make([]byte, n)
+	_, err := io.ReadFull(r, p)
+	return p, err
+}
+
+func readMsgpackString(r *bufio.Reader, n int) (interface{}, error) {
+	p, err := readMsgpackBytes(r, n)
+	return string(p), err
+}
+
+// readMsgpackArray decodes n values, values are appended as they are read so length does not allocate
+func readMsgpackArray(r *bufio.Reader, n, depth int) (interface{}, error) {
+	if n > msgpackMaxLength || depth >= msgpackMaxDepth {
+		return nil, errMsgpack
+	}
+	values := make([]interface{}, 0)
+	for i := 0; i < n; i++ {
+		value, err := decodeMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func readMsgpackMap(r *bufio.Reader, n, depth int) (interface{}, error) {
+	if n > msgpackMaxLength || depth >= msgpackMaxDepth {
+		return nil, errMsgpack
+	}
+	values := make(map[string]interface{})
+	for i := 0; i < n; i++ {
+		key, err := decodeMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeMsgpackDepth(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		values[fmt.Sprint(key)] = value
+	}
+	return values, nil
+}
+
+func readMsgpackExt(r *bufio.Reader, n int) (interface{}, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readMsgpackBytes(r, n)
+	return msgpackExt{Type: int8(typ), Data: data}, err
+}
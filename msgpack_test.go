@@ -0,0 +1,116 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_appendMsgpack(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []byte
+	}{
+		{name: "must encodes nil", value: nil, want: []byte{0xc0}},
+		{name: "must encodes true", value: true, want: []byte{0xc3}},
+		{name: "must encodes positive fixint", value: 5, want: []byte{0x05}},
+		{name: "must encodes negative fixint", value: -5, want: []byte{0xfb}},
+		{name: "must encodes uint16", value: uint16(300), want: []byte{0xcd, 0x01, 0x2c}},
+		{name: "must encodes int32", value: int32(-70000), want: []byte{0xd2, 0xff, 0xfe, 0xee, 0x90}},
+		{name: "must encodes float64", value: 1.5, want: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "must encodes fixstr", value: "abc", want: []byte{0xa3, 'a', 'b', 'c'}},
+		{name: "must encodes binary", value: []byte{1, 2}, want: []byte{0xc4, 0x02, 1, 2}},
+		{name: "must encodes array", value: []int{1, 2}, want: []byte{0x92, 0x01, 0x02}},
+		{name: "must encodes map", value: map[string]int{"a": 1}, want: []byte{0x81, 0xa1, 'a', 0x01}},
+		{name: "must encodes error as string", value: errors.New("e"), want: []byte{0xa1, 'e'}},
+		{
+			name:  "must encodes event time",
+			value: eventTime(time.Unix(1, 2)),
+			want:  []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, appendMsgpack(nil, tt.value))
+		})
+	}
+	t.Run("must encodes address of values which refer to themselves", func(t *testing.T) {
+		type node struct {
+			Next *node `json:"next"`
+		}
+		n := new(node)
+		n.Next = n
+		m := map[string]interface{}{}
+		m["self"] = m
+		s := []interface{}{nil}
+		s[0] = s
+		for _, value := range []interface{}{n, m, s} {
+			got, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(appendMsgpack(nil, value))))
+			assert.NoError(t, err)
+			assert.Contains(t, fmt.Sprint(got), fmt.Sprintf("%p", value))
+		}
+	})
+	t.Run("must encodes shared values which do not refer to themselves", func(t *testing.T) {
+		shared := []int{1}
+		assert.Equal(t, []byte{0x92, 0x91, 0x01, 0x91, 0x01}, appendMsgpack(nil, []interface{}{shared, shared}))
+	})
+}
+
+func Test_decodeMsgpack(t *testing.T) {
+	type user struct {
+		Name   string `json:"name"`
+		Secret string `json:"-"`
+	}
+	long := strings.Repeat("a", 70000)
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "must decodes integers", value: []interface{}{0, 127, 255, 65535, 1 << 40, -1, -100, -30000, -1 << 40}, want: []interface{}{int64(0), int64(127), uint64(255), uint64(65535), uint64(1 << 40), int64(-1), int64(-100), int64(-30000), int64(-1 << 40)}},
+		{name: "must decodes floats", value: []interface{}{float32(1.5), math.Pi}, want: []interface{}{1.5, math.Pi}},
+		{name: "must decodes strings", value: []string{"", "abc", strings.Repeat("b", 40), long}, want: []interface{}{"", "abc", strings.Repeat("b", 40), long}},
+		{name: "must decodes structs as maps", value: &user{Name: "john", Secret: "secret"}, want: map[string]interface{}{"name": "john"}},
+		{name: "must decodes namespaces", value: Namespace{"db": Namespace{"rows": uint(3)}}, want: map[string]interface{}{"db": map[string]interface{}{"rows": int64(3)}}},
+		{name: "must decodes extensions", value: eventTime(time.Unix(1, 2)), want: msgpackExt{Type: 0, Data: []byte{0, 0, 0, 1, 0, 0, 0, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(appendMsgpack(nil, tt.value))))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	t.Run("must returns error of invalid code", func(t *testing.T) {
+		_, err := decodeMsgpack(bufio.NewReader(bytes.NewReader([]byte{0xc1})))
+		assert.Equal(t, errMsgpack, err)
+	})
+	t.Run("must returns error of too long lengths", func(t *testing.T) {
+		for _, value := range [][]byte{
+			{0xdb, 0xff, 0xff, 0xff, 0xff},
+			{0xc6, 0xff, 0xff, 0xff, 0xff},
+			{0xc9, 0xff, 0xff, 0xff, 0xff, 0x00},
+			{0xdd, 0xff, 0xff, 0xff, 0xff},
+			{0xdf, 0xff, 0xff, 0xff, 0xff},
+		} {
+			_, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(value)))
+			assert.Equal(t, errMsgpack, err)
+		}
+	})
+	t.Run("must returns error of too deep nesting", func(t *testing.T) {
+		_, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(bytes.Repeat([]byte{0x91}, msgpackMaxDepth+1))))
+		assert.Equal(t, errMsgpack, err)
+	})
+	t.Run("must returns error of truncated array without allocating its length", func(t *testing.T) {
+		_, err := decodeMsgpack(bufio.NewReader(bytes.NewReader([]byte{0xdd, 0x00, 0x0f, 0xff, 0xff, 0x01})))
+		assert.Equal(t, io.EOF, err)
+	})
+}
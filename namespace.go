@@ -0,0 +1,61 @@
+package log
+
+// Namespace type of nested data which added by grouped entry
+type Namespace map[string]interface{}
+
+// Collision type of policy for data which collides with constants
+type Collision int
+
+const (
+	// CollisionReplace replaces constant value with data value
+	CollisionReplace Collision = iota
+
+	// CollisionKeep keeps constant value and drops data value
+	CollisionKeep
+
+	// CollisionRename keeps constant value and moves data value into fields namespace
+	CollisionRename
+)
+
+const dataFields = "fields"
+
+func (ns Namespace) child(name string) Namespace {
+	child, ok := ns[name].(Namespace)
+	if !ok {
+		child = make(Namespace)
+		ns[name] = child
+	}
+	return child
+}
+
+// mergeData copies src into dst, namespaces are merged into new namespaces so src is not changed
+func mergeData(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if ns, ok := value.(Namespace); ok {
+			merged := make(Namespace)
+			if prev, ok := dst[key].(Namespace); ok {
+				mergeData(merged, prev)
+			}
+			mergeData(merged, ns)
+			value = merged
+		}
+		dst[key] = value
+	}
+}
+
+// flattenNamespaces returns data with namespaces flattened into dotted keys
+func flattenNamespaces(data map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{}, len(data))
+	var walk func(prefix string, data map[string]interface{})
+	walk = func(prefix string, data map[string]interface{}) {
+		for key, value := range data {
+			if ns, ok := value.(Namespace); ok {
+				walk(prefix+key+".", ns)
+				continue
+			}
+			flat[prefix+key] = value
+		}
+	}
+	walk("", data)
+	return flat
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_mergeData(t *testing.T) {
+	t.Run("must merges namespaces without changing source", func(t *testing.T) {
+		parent := map[string]interface{}{"db": Namespace{"query": "select"}}
+		child := map[string]interface{}{"db": Namespace{"rows": 10}}
+		merged := make(map[string]interface{})
+		mergeData(merged, parent)
+		mergeData(merged, child)
+		assert.Equal(t, map[string]interface{}{"db": Namespace{"query": "select", "rows": 10}}, merged)
+		assert.Equal(t, Namespace{"query": "select"}, parent["db"])
+		assert.Equal(t, Namespace{"rows": 10}, child["db"])
+	})
+	t.Run("must replaces plain maps", func(t *testing.T) {
+		merged := map[string]interface{}{"key": map[string]interface{}{"a": 1}}
+		mergeData(merged, map[string]interface{}{"key": map[string]interface{}{"b": 2}})
+		assert.Equal(t, map[string]interface{}{"key": map[string]interface{}{"b": 2}}, merged)
+	})
+}
+
+func Test_flattenNamespaces(t *testing.T) {
+	t.Run("must returns dotted keys of namespaces", func(t *testing.T) {
+		data := map[string]interface{}{
+			"key": "value",
+			"db":  Namespace{"query": "select", "pool": Namespace{"size": 10}},
+			"map": map[string]interface{}{"a": 1},
+		}
+		assert.Equal(t, map[string]interface{}{
+			"key":          "value",
+			"db.query":     "select",
+			"db.pool.size": 10,
+			"map":          map[string]interface{}{"a": 1},
+		}, flattenNamespaces(data))
+	})
+}
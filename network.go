@@ -0,0 +1,487 @@
+package log
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSpoolFull raises when entry can not spool because spool of sink is full
+	ErrSpoolFull = errors.New("log: spool of sink is full")
+
+	// ErrUnreachable raises when entry which is kept in memory is dropped because endpoint is unreachable on close
+	ErrUnreachable = errors.New("log: endpoint is unreachable")
+
+	// ErrSpooled raises on flush while entries are kept in spool until endpoint is reachable
+	ErrSpooled = errors.New("log: entries are spooled until endpoint is reachable")
+)
+
+// Framing type of message framing on stream connections
+type Framing int
+
+const (
+	// FramingNewline terminates messages with newline, messages must not contain newlines
+	FramingNewline Framing = iota
+
+	// FramingOctetCounting prefixes messages with decimal length and space of rfc6587
+	FramingOctetCounting
+
+	// FramingLengthPrefix prefixes messages with 4 bytes big endian length
+	FramingLengthPrefix
+)
+
+// frame returns message which is framed for stream connection
+func (f Framing) frame(msg string) []byte {
+	msg = strings.TrimRight(msg, "\n")
+	switch f {
+	case FramingOctetCounting:
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	case FramingLengthPrefix:
+		framed := make([]byte, 4+len(msg))
+		binary.BigEndian.PutUint32(framed, uint32(len(msg)))
+		copy(framed[4:], msg)
+		return framed
+	default:
+		return []byte(msg + "\n")
+	}
+}
+
+// NetworkOption type of network sink option
+type NetworkOption func(*NetworkSink)
+
+// NetworkTLS sets tls config of tls network
+func NetworkTLS(config *tls.Config) NetworkOption {
+	return func(s *NetworkSink) {
+		s.tlsConfig = config
+	}
+}
+
+// NetworkFraming sets framing of messages (default: FramingNewline)
+func NetworkFraming(framing Framing) NetworkOption {
+	return func(s *NetworkSink) {
+		s.framing = framing
+	}
+}
+
+// NetworkFormatter sets formatter of messages (default: json formatter)
+func NetworkFormatter(f Formatter) NetworkOption {
+	return func(s *NetworkSink) {
+		s.formatter = f
+	}
+}
+
+// NetworkTimeout sets timeout of dialing and writing (default: 5s)
+func NetworkTimeout(timeout time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.timeout = timeout
+	}
+}
+
+// NetworkBackoff sets backoff delays of reconnecting (default: 100ms, 30s)
+func NetworkBackoff(min, max time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.minBackoff = min
+		s.maxBackoff = max
+	}
+}
+
+// NetworkBuffer sets capacity of queued entries and entries which are kept in memory
+// while endpoint is unreachable without spool, entries are dropped when queue is full (default: 10000)
+func NetworkBuffer(capacity int) NetworkOption {
+	return func(s *NetworkSink) {
+		s.capacity = capacity
+	}
+}
+
+// NetworkSpool sets directory of on-disk spool which keeps entries up to size bytes while endpoint is unreachable,
+// spooled entries of previous runs are replayed too
+func NetworkSpool(dir string, size int64) NetworkOption {
+	return func(s *NetworkSink) {
+		s.spoolDir = dir
+		s.spoolSize = size
+	}
+}
+
+// NetworkSink implements sink of formatted messages on tcp or tls endpoint, failed connections are reconnected
+// with backoff and messages are spooled while endpoint is unreachable and replayed in order on reconnect
+type NetworkSink struct {
+	network    string
+	address    string
+	tlsConfig  *tls.Config
+	framing    Framing
+	formatter  Formatter
+	timeout    time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	capacity   int
+	spoolDir   string
+	spoolSize  int64
+
+	conn    net.Conn
+	closed  chan struct{}
+	spool   spool
+	queue   chan spooledEntry
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+type spooledEntry struct {
+	entry Entry
+	msg   []byte
+}
+
+// NewNetworkSink returns new network sink of tcp or tls network and address, endpoint is connected in background
+func NewNetworkSink(network, address string, options ...NetworkOption) (*NetworkSink, error) {
+	s := &NetworkSink{
+		network:    network,
+		address:    address,
+		formatter:  NewJSONFormatter(),
+		timeout:    5 * time.Second,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		capacity:   10000,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.formatter = sinkFormatter(s.formatter)
+	if s.spoolDir != "" {
+		spool, err := openFileSpool(s.spoolDir, s.spoolSize)
+		if err != nil {
+			return nil, err
+		}
+		s.spool = spool
+	} else {
+		s.spool = &memorySpool{capacity: s.capacity}
+	}
+	s.queue = make(chan spooledEntry, s.capacity)
+	s.flushes = make(chan chan error)
+	s.done = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.run()
+	return s, nil
+}
+
+// Send queues formatted entry to write without blocking
+func (s *NetworkSink) Send(entry Entry) error {
+	select {
+	case <-s.done:
+		return ErrQueueFull
+	default:
+	}
+	select {
+	case s.queue <- spooledEntry{entry: entry, msg: s.framing.frame(s.formatter.Format(entry))}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Flush writes or spools queued entries, it returns last error of failed entries since previous flush
+// or ErrSpooled while entries are kept in spool
+func (s *NetworkSink) Flush() error {
+	ack := make(chan error)
+	select {
+	case s.flushes <- ack:
+		return <-ack
+	case <-s.stopped:
+		return nil
+	}
+}
+
+// Close writes or spools queued entries and closes connection and spool
+func (s *NetworkSink) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+func (s *NetworkSink) run() {
+	defer close(s.stopped)
+	attempt := 0
+	var failed error
+	reconnect := time.NewTimer(0)
+	defer reconnect.Stop()
+	disconnect := func() {
+		_ = s.conn.Close()
+		s.conn = nil
+		attempt = 0
+		reconnect.Reset(backoff(attempt, s.minBackoff, s.maxBackoff))
+	}
+	handle := func(spooled spooledEntry) {
+		if s.conn != nil {
+			if err := s.write(spooled.msg); err == nil {
+				return
+			}
+			disconnect()
+		}
+		if err := s.spool.push(spooled); err != nil {
+			sendFailed(spooled.entry, err)
+			failed = err
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case spooled := <-s.queue:
+				handle(spooled)
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case spooled := <-s.queue:
+			handle(spooled)
+		case <-reconnect.C:
+			if err := s.connect(); err != nil {
+				attempt++
+				reconnect.Reset(backoff(attempt, s.minBackoff, s.maxBackoff))
+				continue
+			}
+			if err := s.replay(); err != nil {
+				disconnect()
+			}
+		case ack := <-s.flushes:
+			drain()
+			err := failed
+			if err == nil && !s.spool.empty() {
+				err = ErrSpooled
+			}
+			failed = nil
+			ack <- err
+		case <-s.done:
+			drain()
+			if s.conn != nil {
+				_ = s.conn.Close()
+			}
+			_ = s.spool.close()
+			return
+		}
+	}
+}
+
+// replay writes spooled messages in order
+func (s *NetworkSink) replay() error {
+	for !s.spool.empty() {
+		msg, err := s.spool.peek()
+		if err != nil {
+			return err
+		}
+		if err := s.write(msg); err != nil {
+			return err
+		}
+		if err := s.spool.pop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NetworkSink) write(msg []byte) error {
+	select {
+	case <-s.closed:
+		return io.EOF
+	default:
+	}
+	if s.timeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// connect dials endpoint and watches connection for closing by peer so messages are not written on closed connection
+func (s *NetworkSink) connect() error {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	var conn net.Conn
+	var err error
+	if s.network == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(s.network, s.address)
+	}
+	if err != nil {
+		return err
+	}
+	closed := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, conn)
+		close(closed)
+	}()
+	s.conn, s.closed = conn, closed
+	return nil
+}
+
+// spool interface of queue which keeps messages while endpoint is unreachable
+type spool interface {
+	push(spooled spooledEntry) error
+	peek() ([]byte, error)
+	pop() error
+	empty() bool
+	close() error
+}
+
+// memorySpool implements spool of entries in memory up to capacity entries, entries are reported as failed on close
+type memorySpool struct {
+	capacity int
+	entries  []spooledEntry
+}
+
+func (s *memorySpool) push(spooled spooledEntry) error {
+	if len(s.entries) >= s.capacity {
+		return ErrSpoolFull
+	}
+	s.entries = append(s.entries, spooled)
+	return nil
+}
+
+func (s *memorySpool) peek() ([]byte, error) {
+	return s.entries[0].msg, nil
+}
+
+func (s *memorySpool) pop() error {
+	s.entries[0] = spooledEntry{}
+	s.entries = s.entries[1:]
+	return nil
+}
+
+func (s *memorySpool) empty() bool {
+	return len(s.entries) == 0
+}
+
+func (s *memorySpool) close() error {
+	for _, spooled := range s.entries {
+		sendFailed(spooled.entry, ErrUnreachable)
+	}
+	s.entries = nil
+	return nil
+}
+
+// fileSpool implements spool of messages in file up to limit bytes as length prefixed records,
+// file is truncated when all records are popped
+type fileSpool struct {
+	file   *os.File
+	limit  int64
+	size   int64
+	offset int64
+}
+
+func openFileSpool(dir string, limit int64) (*fileSpool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, "spool"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	s := &fileSpool{file: file, limit: limit}
+	if err := s.recover(info.Size()); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover sets size of spool to end of last complete record and truncates record of interrupted write
+func (s *fileSpool) recover(size int64) error {
+	header := make([]byte, 4)
+	for s.size+4 <= size {
+		if _, err := s.file.ReadAt(header, s.size); err != nil {
+			return err
+		}
+		next := s.size + 4 + int64(binary.BigEndian.Uint32(header))
+		if next > size {
+			break
+		}
+		s.size = next
+	}
+	if s.size < size {
+		return s.file.Truncate(s.size)
+	}
+	return nil
+}
+
+func (s *fileSpool) push(spooled spooledEntry) error {
+	msg := spooled.msg
+	if s.size+int64(4+len(msg)) > s.limit {
+		return ErrSpoolFull
+	}
+	record := make([]byte, 4+len(msg))
+	binary.BigEndian.PutUint32(record, uint32(len(msg)))
+	copy(record[4:], msg)
+	if _, err := s.file.WriteAt(record, s.size); err != nil {
+		return err
+	}
+	s.size += int64(len(record))
+	return nil
+}
+
+func (s *fileSpool) peek() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := s.file.ReadAt(header, s.offset); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := s.file.ReadAt(msg, s.offset+4); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *fileSpool) pop() error {
+	header := make([]byte, 4)
+	if _, err := s.file.ReadAt(header, s.offset); err != nil {
+		return err
+	}
+	s.offset += 4 + int64(binary.BigEndian.Uint32(header))
+	if s.offset < s.size {
+		return nil
+	}
+	s.size, s.offset = 0, 0
+	return s.file.Truncate(0)
+}
+
+func (s *fileSpool) empty() bool {
+	return s.offset >= s.size
+}
+
+// close moves records which are not popped to start of file so they are replayed once on next open
+func (s *fileSpool) close() error {
+	if s.offset > 0 {
+		rest := make([]byte, s.size-s.offset)
+		if _, err := s.file.ReadAt(rest, s.offset); err != nil {
+			_ = s.file.Close()
+			return err
+		}
+		if _, err := s.file.WriteAt(rest, 0); err != nil {
+			_ = s.file.Close()
+			return err
+		}
+		if err := s.file.Truncate(int64(len(rest))); err != nil {
+			_ = s.file.Close()
+			return err
+		}
+	}
+	return s.file.Close()
+}
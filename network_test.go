@@ -0,0 +1,257 @@
+package log
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testNetworkServer struct {
+	listener net.Listener
+	lines    chan string
+	mu       sync.Mutex
+	conns    []net.Conn
+}
+
+// startTestNetworkServer reads newline framed lines of connections on address
+func startTestNetworkServer(t *testing.T, address string, config *tls.Config) *testNetworkServer {
+	var listener net.Listener
+	var err error
+	for attempt := 0; attempt < 50; attempt++ {
+		if config != nil {
+			listener, err = tls.Listen("tcp", address, config)
+		} else {
+			listener, err = net.Listen("tcp", address)
+		}
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+	server := &testNetworkServer{listener: listener, lines: make(chan string, 100)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					server.lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return server
+}
+
+func (s *testNetworkServer) stop() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+func (s *testNetworkServer) receive(t *testing.T, count int) []string {
+	var lines []string
+	for i := 0; i < count; i++ {
+		select {
+		case line := <-s.lines:
+			lines = append(lines, line)
+		case <-time.After(3 * time.Second):
+			t.Fatal("line is not received")
+		}
+	}
+	return lines
+}
+
+func testMessageFormatter(t *testing.T) Formatter {
+	f, err := NewTemplateFormatter("{{.Message}}")
+	assert.NoError(t, err)
+	return f
+}
+
+func sendTestMessages(t *testing.T, sink Sink, from, to int) []string {
+	var messages []string
+	for i := from; i <= to; i++ {
+		message := fmt.Sprintf("message %d", i)
+		assert.NoError(t, sink.Send(Entry{Message: message}))
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestNetworkSink_Send(t *testing.T) {
+	resetTest()
+	dir, err := ioutil.TempDir("", "network")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	serverTLS, clientTLS := testTLSConfigs(t)
+	tests := []struct {
+		name    string
+		network string
+		config  *tls.Config
+		options []NetworkOption
+	}{
+		{
+			name:    "must replays entries of disk spool in order after restart of endpoint",
+			network: "tcp",
+			options: []NetworkOption{NetworkSpool(dir, 1<<20)},
+		},
+		{
+			name:    "must replays entries of memory spool in order after restart of endpoint",
+			network: "tcp",
+		},
+		{
+			name:    "must replays entries in order after restart of tls endpoint",
+			network: "tls",
+			config:  serverTLS,
+			options: []NetworkOption{NetworkTLS(clientTLS)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startTestNetworkServer(t, "127.0.0.1:0", tt.config)
+			address := server.listener.Addr().String()
+			options := append([]NetworkOption{
+				NetworkFormatter(testMessageFormatter(t)),
+				NetworkBackoff(10*time.Millisecond, 50*time.Millisecond),
+			}, tt.options...)
+			sink, err := NewNetworkSink(tt.network, address, options...)
+			assert.NoError(t, err)
+			defer sink.Close()
+
+			assert.Equal(t, sendTestMessages(t, sink, 1, 3), server.receive(t, 3))
+			server.stop()
+			time.Sleep(50 * time.Millisecond)
+			spooled := sendTestMessages(t, sink, 4, 6)
+			assert.Equal(t, ErrSpooled, sink.Flush())
+			server = startTestNetworkServer(t, address, tt.config)
+			defer server.stop()
+			assert.Equal(t, spooled, server.receive(t, 3))
+			assert.Equal(t, sendTestMessages(t, sink, 7, 7), server.receive(t, 1))
+			assert.NoError(t, sink.Flush())
+		})
+	}
+	t.Run("must writes messages without colors when output is terminal", func(t *testing.T) {
+		defer resetTest()
+		outputTerminal = true
+		server := startTestNetworkServer(t, "127.0.0.1:0", nil)
+		defer server.stop()
+		sink, err := NewNetworkSink("tcp", server.listener.Addr().String(), NetworkFormatter(NewTextFormatter(TextSingleLine())))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(Entry{Level: LevelError, Message: "message"}))
+		assert.NotContains(t, server.receive(t, 1)[0], "\033[")
+	})
+	t.Run("must reports entries when spool is full", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		_ = listener.Close()
+		sink, err := NewNetworkSink("tcp", listener.Addr().String(), NetworkBuffer(2))
+		assert.NoError(t, err)
+		before := GetStats()
+		sendTestMessages(t, sink, 1, 2)
+		assert.Equal(t, ErrSpooled, sink.Flush())
+		sendTestMessages(t, sink, 3, 3)
+		assert.Equal(t, ErrSpoolFull, sink.Flush())
+		assert.NoError(t, sink.Close())
+		assert.Equal(t, before.FailedSends+3, GetStats().FailedSends)
+	})
+}
+
+func TestFraming_frame(t *testing.T) {
+	tests := []struct {
+		name    string
+		framing Framing
+		want    []byte
+	}{
+		{
+			name:    "must terminates message with newline",
+			framing: FramingNewline,
+			want:    []byte("message\n"),
+		},
+		{
+			name:    "must prefixes message with octet count",
+			framing: FramingOctetCounting,
+			want:    []byte("7 message"),
+		},
+		{
+			name:    "must prefixes message with big endian length",
+			framing: FramingLengthPrefix,
+			want:    append([]byte{0, 0, 0, 7}, "message"...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.framing.frame("message\n"))
+		})
+	}
+}
+
+func Test_fileSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pop := func(s *fileSpool) string {
+		msg, err := s.peek()
+		assert.NoError(t, err)
+		assert.NoError(t, s.pop())
+		return string(msg)
+	}
+	t.Run("must keeps records which are not popped after reopen", func(t *testing.T) {
+		s, err := openFileSpool(dir, 1<<10)
+		assert.NoError(t, err)
+		for _, msg := range []string{"first", "second", "third"} {
+			assert.NoError(t, s.push(spooledEntry{msg: []byte(msg)}))
+		}
+		assert.Equal(t, "first", pop(s))
+		assert.NoError(t, s.close())
+		s, err = openFileSpool(dir, 1<<10)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", pop(s))
+		assert.Equal(t, "third", pop(s))
+		assert.True(t, s.empty())
+		assert.NoError(t, s.close())
+	})
+	t.Run("must truncates record of interrupted write", func(t *testing.T) {
+		s, err := openFileSpool(dir, 1<<10)
+		assert.NoError(t, err)
+		assert.NoError(t, s.push(spooledEntry{msg: []byte("complete")}))
+		assert.NoError(t, s.close())
+		file, err := os.OpenFile(filepath.Join(dir, "spool"), os.O_WRONLY|os.O_APPEND, 0600)
+		assert.NoError(t, err)
+		_, err = file.Write([]byte{0, 0, 0, 10, 'p', 'a'})
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+		s, err = openFileSpool(dir, 1<<10)
+		assert.NoError(t, err)
+		assert.NoError(t, s.push(spooledEntry{msg: []byte("next")}))
+		assert.Equal(t, "complete", pop(s))
+		assert.Equal(t, "next", pop(s))
+		assert.True(t, s.empty())
+		assert.NoError(t, s.close())
+	})
+	t.Run("must returns error when limit is exceeded", func(t *testing.T) {
+		s, err := openFileSpool(dir, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, s.push(spooledEntry{msg: []byte("123456")}))
+		assert.Equal(t, ErrSpoolFull, s.push(spooledEntry{msg: []byte("1")}))
+		assert.NoError(t, s.close())
+	})
+}
@@ -0,0 +1,357 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const otlpScope = "github.com/golage/log"
+
+// OTLPOption type of otlp exporter option
+type OTLPOption func(*OTLPExporter)
+
+// OTLPHeaders sets headers of requests such as authorization
+func OTLPHeaders(headers map[string]string) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.headers = headers
+	}
+}
+
+// OTLPBatch sets maximum size and wait duration of batches (default: 512, 1s)
+func OTLPBatch(size int, wait time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.size = size
+		e.wait = wait
+	}
+}
+
+// OTLPBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 10000)
+func OTLPBuffer(capacity int) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.capacity = capacity
+	}
+}
+
+// OTLPRetry sets retries of failed exports and their backoff delays, Retry-After header of responses
+// overrides backoff delay (default: 5, 1s, 30s)
+func OTLPRetry(retries int, min, max time.Duration) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.retry = httpRetry{retries: retries, min: min, max: max}
+	}
+}
+
+// OTLPClient sets http client of exports (default: client with 10s timeout)
+func OTLPClient(client *http.Client) OTLPOption {
+	return func(e *OTLPExporter) {
+		e.client = client
+	}
+}
+
+// OTLPExporter implements sink of opentelemetry logs over otlp/http with json encoding,
+// constants are exported as resource attributes and data as log record attributes
+type OTLPExporter struct {
+	url      string
+	headers  map[string]string
+	size     int
+	wait     time.Duration
+	capacity int
+	retry    httpRetry
+	client   *http.Client
+
+	batcher *batcher
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewOTLPExporter returns new otlp exporter which exports on /v1/logs of collector endpoint
+func NewOTLPExporter(endpoint string, options ...OTLPOption) *OTLPExporter {
+	e := &OTLPExporter{
+		url:      strings.TrimRight(endpoint, "/") + "/v1/logs",
+		size:     512,
+		wait:     time.Second,
+		capacity: 10000,
+		retry:    httpRetry{retries: 5, min: time.Second, max: 30 * time.Second},
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		option(e)
+	}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	e.batcher = newBatcher(e.size, e.wait, e.capacity, e.export)
+	return e
+}
+
+// Send queues entry to export in next batch
+func (e *OTLPExporter) Send(entry Entry) error {
+	return e.batcher.add(entry)
+}
+
+// Flush exports queued entries, it returns last error of failed entries since previous flush
+func (e *OTLPExporter) Flush() error {
+	return e.batcher.sync()
+}
+
+// Shutdown exports queued entries and stops exporter, pending exports are canceled when context is done
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		e.batcher.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close exports queued entries and stops exporter
+func (e *OTLPExporter) Close() error {
+	return e.Shutdown(context.Background())
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue map[string]interface{}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+	Flags                int            `json:"flags,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpResourceLogs struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+func (e *OTLPExporter) export(entries []Entry) error {
+	body, err := json.Marshal(otlpRequest(entries, time.Now()))
+	if err == nil {
+		_, err = e.retry.do(e.client, func() (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req = req.WithContext(e.ctx)
+			req.Header.Set("Content-Type", "application/json")
+			for key, value := range e.headers {
+				req.Header.Set(key, value)
+			}
+			return req, nil
+		})
+	}
+	if err != nil {
+		for _, entry := range entries {
+			sendFailed(entry, err)
+		}
+	}
+	return err
+}
+
+// otlpRequest returns export request of entries grouped by resource attributes
+func otlpRequest(entries []Entry, observed time.Time) interface{} {
+	var resources []*otlpResourceLogs
+	index := make(map[string]*otlpResourceLogs)
+	for _, entry := range entries {
+		meta, data := splitConstants(entry.Data)
+		attributes := otlpAttributes(otlpResource(meta))
+		key := fmt.Sprint(attributes)
+		resource, ok := index[key]
+		if !ok {
+			resource = &otlpResourceLogs{ScopeLogs: make([]otlpScopeLogs, 1)}
+			resource.Resource.Attributes = attributes
+			resource.ScopeLogs[0].Scope.Name = otlpScope
+			index[key] = resource
+			resources = append(resources, resource)
+		}
+		resource.ScopeLogs[0].LogRecords = append(resource.ScopeLogs[0].LogRecords, otlpRecord(entry, data, observed))
+	}
+	return map[string]interface{}{"resourceLogs": resources}
+}
+
+// otlpResource returns resource attributes of constants with semantic convention names of service metadata
+func otlpResource(meta map[string]interface{}) map[string]interface{} {
+	names := map[string]string{
+		constantService: "service.name",
+		constantVersion: "service.version",
+		constantEnv:     "deployment.environment",
+	}
+	resource := make(map[string]interface{}, len(meta))
+	for key, value := range meta {
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		resource[key] = value
+	}
+	return resource
+}
+
+func otlpRecord(entry Entry, data map[string]interface{}, observed time.Time) otlpLogRecord {
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(entry.Raised.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(observed.UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(entry.Level),
+		SeverityText:         strings.ToUpper(entry.Level.String()),
+		Body:                 otlpValue(strings.TrimSpace(entry.Message)),
+	}
+	if trace, ok := otlpID(data[traceKeys.TraceID], 16); ok {
+		record.TraceID = trace
+		delete(data, traceKeys.TraceID)
+	}
+	if span, ok := otlpID(data[traceKeys.SpanID], 8); ok {
+		record.SpanID = span
+		delete(data, traceKeys.SpanID)
+	}
+	if flags, ok := traceFlags(data[traceKeys.Flags]); ok {
+		record.Flags = int(flags)
+		delete(data, traceKeys.Flags)
+	}
+	if function, file, line := sourceLocation(entry.Source); file != "" {
+		data["code.function"] = function
+		data["code.filepath"] = file
+		data["code.lineno"] = line
+	}
+	record.Attributes = otlpAttributes(data)
+	return record
+}
+
+// otlpSeverity returns opentelemetry severity number of level
+func otlpSeverity(lvl Level) int {
+	switch lvl {
+	case LevelDebug:
+		return 5
+	case LevelInfo:
+		return 9
+	case LevelWarning:
+		return 13
+	case LevelError:
+		return 17
+	case LevelFatal:
+		return 21
+	default:
+		return 0
+	}
+}
+
+// otlpID returns lower hex id of value when it is valid id of size bytes
+func otlpID(value interface{}, size int) (string, bool) {
+	id, ok := value.(string)
+	if !ok || len(id) != size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil || strings.Trim(id, "0") == "" {
+		return "", false
+	}
+	return strings.ToLower(id), true
+}
+
+func otlpAttributes(data map[string]interface{}) []otlpKeyValue {
+	return otlpAttributesVisited(data, make(map[uintptr]bool))
+}
+
+func otlpAttributesVisited(data map[string]interface{}, visited map[uintptr]bool) []otlpKeyValue {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attributes := make([]otlpKeyValue, 0, len(keys))
+	for _, key := range keys {
+		attributes = append(attributes, otlpKeyValue{Key: key, Value: otlpValueVisited(data[key], visited)})
+	}
+	return attributes
+}
+
+// otlpValue returns any value of opentelemetry json encoding, integers and non-finite floats are encoded
+// as strings and maps and slices which refer to themselves are encoded as their address
+func otlpValue(value interface{}) otlpAnyValue {
+	return otlpValueVisited(value, make(map[uintptr]bool))
+}
+
+// otlpValueVisited returns any value of value, visited keeps addresses of maps and slices on path of value
+// to detect cycles
+func otlpValueVisited(value interface{}, visited map[uintptr]bool) otlpAnyValue {
+	if ref := reflect.ValueOf(value); (ref.Kind() == reflect.Map || ref.Kind() == reflect.Slice) && !ref.IsNil() {
+		if visited[ref.Pointer()] {
+			return otlpAnyValue{"stringValue": fmt.Sprintf("%p", value)}
+		}
+		visited[ref.Pointer()] = true
+		defer delete(visited, ref.Pointer())
+	}
+	switch value := value.(type) {
+	case nil:
+		return otlpAnyValue{}
+	case string:
+		return otlpAnyValue{"stringValue": value}
+	case bool:
+		return otlpAnyValue{"boolValue": value}
+	case []byte:
+		return otlpAnyValue{"bytesValue": value}
+	case Namespace:
+		return otlpAnyValue{"kvlistValue": map[string]interface{}{"values": otlpAttributesVisited(value, visited)}}
+	case map[string]interface{}:
+		return otlpAnyValue{"kvlistValue": map[string]interface{}{"values": otlpAttributesVisited(value, visited)}}
+	case error:
+		return otlpAnyValue{"stringValue": value.Error()}
+	case fmt.Stringer:
+		return otlpAnyValue{"stringValue": value.String()}
+	}
+	ref := reflect.ValueOf(value)
+	switch ref.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return otlpAnyValue{"intValue": strconv.FormatInt(ref.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return otlpAnyValue{"intValue": strconv.FormatUint(ref.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		if f := ref.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return otlpAnyValue{"stringValue": strconv.FormatFloat(f, 'g', -1, 64)}
+		}
+		return otlpAnyValue{"doubleValue": ref.Float()}
+	case reflect.Slice, reflect.Array:
+		values := make([]otlpAnyValue, ref.Len())
+		for i := range values {
+			values[i] = otlpValueVisited(ref.Index(i).Interface(), visited)
+		}
+		return otlpAnyValue{"arrayValue": map[string]interface{}{"values": values}}
+	case reflect.Map, reflect.Struct, reflect.Ptr:
+		var decoded interface{}
+		if bytes, err := json.Marshal(value); err == nil && json.Unmarshal(bytes, &decoded) == nil {
+			if _, ok := decoded.(map[string]interface{}); ok || ref.Kind() == reflect.Ptr {
+				return otlpValueVisited(decoded, visited)
+			}
+		}
+	}
+	return otlpAnyValue{"stringValue": fmt.Sprint(value)}
+}
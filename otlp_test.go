@@ -0,0 +1,228 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startTestOTLPCollector decodes exports, it responds with status and Retry-After of failures before accepting
+func startTestOTLPCollector(t *testing.T, failures ...int) (*httptest.Server, <-chan map[string]interface{}) {
+	exports := make(chan map[string]interface{}, 10)
+	var calls uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if call := int(atomic.AddUint64(&calls, 1)); call <= len(failures) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(failures[call-1])
+			return
+		}
+		var export map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&export))
+		exports <- export
+		_, _ = w.Write([]byte("{}"))
+	}))
+	return server, exports
+}
+
+func TestOTLPExporter_Send(t *testing.T) {
+	resetTest()
+	SetConstant("service", "api")
+	entry := Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC),
+		Level:   LevelWarning,
+		Source:  "at main.run in main.go:10",
+		Message: "text message",
+		Data: map[string]interface{}{
+			"service":  "api",
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+			"count":    3,
+		},
+	}
+	receive := func(exports <-chan map[string]interface{}) map[string]interface{} {
+		select {
+		case export := <-exports:
+			return export
+		case <-time.After(3 * time.Second):
+			t.Fatal("export is not received")
+			return nil
+		}
+	}
+	t.Run("must exports log records of resource", func(t *testing.T) {
+		server, exports := startTestOTLPCollector(t)
+		defer server.Close()
+		exporter := NewOTLPExporter(server.URL, OTLPBatch(2, time.Hour))
+		defer exporter.Close()
+		assert.NoError(t, exporter.Send(entry))
+		assert.NoError(t, exporter.Send(entry))
+		resources := receive(exports)["resourceLogs"].([]interface{})
+		assert.Len(t, resources, 1)
+		resource := resources[0].(map[string]interface{})
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "api"}},
+		}, resource["resource"].(map[string]interface{})["attributes"])
+		scope := resource["scopeLogs"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "github.com/golage/log", scope["scope"].(map[string]interface{})["name"])
+		records := scope["logRecords"].([]interface{})
+		assert.Len(t, records, 2)
+		record := records[0].(map[string]interface{})
+		assert.Equal(t, "1586521845123456789", record["timeUnixNano"])
+		assert.NotEmpty(t, record["observedTimeUnixNano"])
+		assert.Equal(t, float64(13), record["severityNumber"])
+		assert.Equal(t, "WARNING", record["severityText"])
+		assert.Equal(t, map[string]interface{}{"stringValue": "text message"}, record["body"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["traceId"])
+		assert.Equal(t, "00f067aa0ba902b7", record["spanId"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "code.filepath", "value": map[string]interface{}{"stringValue": "main.go"}},
+			map[string]interface{}{"key": "code.function", "value": map[string]interface{}{"stringValue": "main.run"}},
+			map[string]interface{}{"key": "code.lineno", "value": map[string]interface{}{"intValue": "10"}},
+			map[string]interface{}{"key": "count", "value": map[string]interface{}{"intValue": "3"}},
+		}, record["attributes"])
+	})
+	t.Run("must retries export after delay of retry after header", func(t *testing.T) {
+		server, exports := startTestOTLPCollector(t, http.StatusTooManyRequests)
+		defer server.Close()
+		exporter := NewOTLPExporter(server.URL, OTLPRetry(1, time.Hour, time.Hour))
+		defer exporter.Close()
+		started := time.Now()
+		assert.NoError(t, exporter.Send(entry))
+		assert.NoError(t, exporter.Flush())
+		assert.NotNil(t, receive(exports))
+		assert.True(t, time.Since(started) >= time.Second)
+	})
+	t.Run("must exports entries with not a number values", func(t *testing.T) {
+		server, exports := startTestOTLPCollector(t)
+		defer server.Close()
+		exporter := NewOTLPExporter(server.URL, OTLPBatch(2, time.Hour))
+		defer exporter.Close()
+		before := GetStats()
+		assert.NoError(t, exporter.Send(Entry{Level: LevelInfo, Message: "invalid", Data: map[string]interface{}{"ratio": math.NaN()}}))
+		assert.NoError(t, exporter.Send(entry))
+		assert.NoError(t, exporter.Flush())
+		records := receive(exports)["resourceLogs"].([]interface{})
+		var messages []interface{}
+		for _, resource := range records {
+			scope := resource.(map[string]interface{})["scopeLogs"].([]interface{})[0].(map[string]interface{})
+			for _, record := range scope["logRecords"].([]interface{}) {
+				messages = append(messages, record.(map[string]interface{})["body"].(map[string]interface{})["stringValue"])
+			}
+		}
+		assert.Equal(t, []interface{}{"invalid", "text message"}, messages)
+		assert.Equal(t, before.FailedSends, GetStats().FailedSends)
+	})
+	t.Run("must cancels pending exports when shutdown context is done", func(t *testing.T) {
+		server, _ := startTestOTLPCollector(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		defer server.Close()
+		exporter := NewOTLPExporter(server.URL, OTLPRetry(5, time.Hour, time.Hour))
+		before := GetStats()
+		assert.NoError(t, exporter.Send(entry))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		err := exporter.Shutdown(ctx)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.True(t, time.Since(started) < time.Second)
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+}
+
+func Test_otlpValue(t *testing.T) {
+	type object struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		want  otlpAnyValue
+	}{
+		{
+			name:  "must encodes string",
+			value: "text",
+			want:  otlpAnyValue{"stringValue": "text"},
+		},
+		{
+			name:  "must encodes bool",
+			value: true,
+			want:  otlpAnyValue{"boolValue": true},
+		},
+		{
+			name:  "must encodes integer as string",
+			value: uint8(10),
+			want:  otlpAnyValue{"intValue": "10"},
+		},
+		{
+			name:  "must encodes float",
+			value: 1.5,
+			want:  otlpAnyValue{"doubleValue": 1.5},
+		},
+		{
+			name:  "must encodes non-finite float as string",
+			value: math.Inf(-1),
+			want:  otlpAnyValue{"stringValue": "-Inf"},
+		},
+		{
+			name:  "must encodes slice as array",
+			value: []int{1},
+			want:  otlpAnyValue{"arrayValue": map[string]interface{}{"values": []otlpAnyValue{{"intValue": "1"}}}},
+		},
+		{
+			name:  "must encodes namespace as key value list",
+			value: Namespace{"key": "value"},
+			want: otlpAnyValue{"kvlistValue": map[string]interface{}{"values": []otlpKeyValue{
+				{Key: "key", Value: otlpAnyValue{"stringValue": "value"}},
+			}}},
+		},
+		{
+			name:  "must encodes struct as key value list of json fields",
+			value: object{Name: "name"},
+			want: otlpAnyValue{"kvlistValue": map[string]interface{}{"values": []otlpKeyValue{
+				{Key: "name", Value: otlpAnyValue{"stringValue": "name"}},
+			}}},
+		},
+		{
+			name:  "must encodes error as string",
+			value: errors.New("failed"),
+			want:  otlpAnyValue{"stringValue": "failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, otlpValue(tt.value))
+		})
+	}
+	t.Run("must encodes address of values which refer to themselves", func(t *testing.T) {
+		m := map[string]interface{}{}
+		m["self"] = m
+		s := []interface{}{nil}
+		s[0] = s
+		assert.Equal(t, otlpAnyValue{"kvlistValue": map[string]interface{}{"values": []otlpKeyValue{
+			{Key: "self", Value: otlpAnyValue{"stringValue": fmt.Sprintf("%p", m)}},
+		}}}, otlpValue(m))
+		assert.Equal(t, otlpAnyValue{"arrayValue": map[string]interface{}{"values": []otlpAnyValue{
+			{"stringValue": fmt.Sprintf("%p", s)},
+		}}}, otlpValue(s))
+	})
+}
+
+func Test_otlpID(t *testing.T) {
+	id, ok := otlpID("00F067AA0BA902B7", 8)
+	assert.True(t, ok)
+	assert.Equal(t, "00f067aa0ba902b7", id)
+	_, ok = otlpID("0000000000000000", 8)
+	assert.False(t, ok)
+	_, ok = otlpID("00f067aa", 8)
+	assert.False(t, ok)
+	_, ok = otlpID(10, 8)
+	assert.False(t, ok)
+}
@@ -0,0 +1,616 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errQueueRecord raises when record of segment file is truncated or does not match its checksum
+var errQueueRecord = errors.New("log: invalid queue record")
+
+const (
+	queueHeaderSize = 8
+	queueAckFile    = "ack"
+	queueSegmentExt = ".wal"
+)
+
+// SyncPolicy type of policy which syncs queue files on disk
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs files after every entry and acknowledgement
+	SyncAlways SyncPolicy = iota
+
+	// SyncInterval syncs files periodically
+	SyncInterval
+
+	// SyncNever leaves syncing files to operating system
+	SyncNever
+)
+
+// DropPolicy type of policy for entries when disk limit of queue is reached
+type DropPolicy int
+
+const (
+	// DropNewest rejects new entries until queued entries are delivered
+	DropNewest DropPolicy = iota
+
+	// DropOldest deletes oldest segments even if their entries are not delivered
+	DropOldest
+)
+
+// QueueOption type of queue sink option
+type QueueOption func(*QueueSink)
+
+// QueueSync sets sync policy of files and interval of SyncInterval (default: SyncInterval, 1s)
+func QueueSync(policy SyncPolicy, interval time.Duration) QueueOption {
+	return func(q *QueueSink) {
+		q.syncPolicy = policy
+		q.syncInterval = interval
+	}
+}
+
+// QueueSegmentSize sets size of segment files in bytes (default: 8MiB)
+func QueueSegmentSize(size int64) QueueOption {
+	return func(q *QueueSink) {
+		q.segmentSize = size
+	}
+}
+
+// QueueLimit sets disk usage limit of segment files in bytes and drop policy when limit is reached
+// (default: 256MiB, DropNewest)
+func QueueLimit(size int64, policy DropPolicy) QueueOption {
+	return func(q *QueueSink) {
+		q.limit = size
+		q.dropPolicy = policy
+	}
+}
+
+// QueueBatch sets count of entries which are delivered before acknowledgement (default: 100)
+func QueueBatch(size int) QueueOption {
+	return func(q *QueueSink) {
+		q.batch = size
+	}
+}
+
+// QueueRetry sets backoff delays of failed deliveries (default: 100ms, 30s)
+func QueueRetry(min, max time.Duration) QueueOption {
+	return func(q *QueueSink) {
+		q.minBackoff = min
+		q.maxBackoff = max
+	}
+}
+
+// QueueStats keeps counters of queue sink
+type QueueStats struct {
+	// Queued keeps count of entries which are appended on disk
+	Queued uint64
+
+	// Delivered keeps count of entries which are delivered on sink
+	Delivered uint64
+
+	// Dropped keeps count of entries which are rejected, deleted by drop policy, can not decode
+	// or are rejected by sink with non-retryable status
+	Dropped uint64
+}
+
+// QueueSink implements durable write-ahead queue in front of sink, entries are appended on segment files
+// and delivered in background, offset of delivered entries is acknowledged on disk so entries
+// which are not delivered are replayed after restart, delivery is at-least-once
+//
+// Entries are acknowledged after Flush of sink succeeds, so batching sinks report failed batches
+// and they are redelivered with backoff, batches which are rejected with 4xx status except 429
+// are dropped because redelivery can not succeed, batches which sink keeps in spool (ErrSpooled)
+// are not sent again and they are acknowledged when a later flush succeeds
+type QueueSink struct {
+	dir          string
+	sink         Sink
+	syncPolicy   SyncPolicy
+	syncInterval time.Duration
+	segmentSize  int64
+	limit        int64
+	dropPolicy   DropPolicy
+	batch        int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+
+	mu       sync.Mutex
+	segments []*queueSegment
+	active   *os.File
+	ack      *os.File
+	total    int64
+	read     queuePosition
+	spooled  *queueBatch
+	stats    QueueStats
+	notify   chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+}
+
+type queueSegment struct {
+	seq  uint64
+	size int64
+}
+
+type queuePosition struct {
+	segment uint64
+	offset  int64
+}
+
+// queueBatch keeps end position and counts of delivered batch which is not acknowledged
+type queueBatch struct {
+	pos     queuePosition
+	count   int
+	dropped int
+}
+
+// NewQueueSink returns new queue sink which keeps segment files in dir and delivers entries on sink,
+// entries which are not acknowledged in dir are replayed
+func NewQueueSink(dir string, sink Sink, options ...QueueOption) (*QueueSink, error) {
+	q := &QueueSink{
+		dir:          dir,
+		sink:         sink,
+		syncPolicy:   SyncInterval,
+		syncInterval: time.Second,
+		segmentSize:  8 << 20,
+		limit:        256 << 20,
+		batch:        100,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		notify:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(q)
+	}
+	if q.batch < 1 {
+		q.batch = 1
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := q.open(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	go q.run()
+	if q.syncPolicy == SyncInterval && q.syncInterval > 0 {
+		go q.syncPeriodically()
+	}
+	return q, nil
+}
+
+// Send appends entry on active segment file, entry is rejected when disk limit is reached with DropNewest
+func (q *QueueSink) Send(entry Entry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		if payload, err = json.Marshal(encodeFailed(entry, err)); err != nil {
+			return err
+		}
+	}
+	record := make([]byte, queueHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	copy(record[queueHeaderSize:], payload)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-q.done:
+		return ErrQueueFull
+	default:
+	}
+	size := int64(len(record))
+	if q.total+size > q.limit && !q.dropOldest(size) {
+		q.stats.Dropped++
+		return ErrQueueFull
+	}
+	last := q.segments[len(q.segments)-1]
+	if last.size > 0 && last.size+size > q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		last = q.segments[len(q.segments)-1]
+	}
+	if _, err := q.active.Write(record); err != nil {
+		_ = q.active.Truncate(last.size)
+		return err
+	}
+	last.size += size
+	q.total += size
+	q.stats.Queued++
+	if q.syncPolicy == SyncAlways {
+		if err := q.active.Sync(); err != nil {
+			return err
+		}
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush syncs segment and acknowledgement files on disk
+func (q *QueueSink) Flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.syncFiles()
+}
+
+// Close delivers queued entries until sink fails, closes files and flushes and closes sink,
+// entries which are not delivered are replayed on next open
+func (q *QueueSink) Close() error {
+	q.once.Do(func() {
+		close(q.done)
+	})
+	<-q.stopped
+	q.mu.Lock()
+	err := q.syncFiles()
+	q.closeFiles()
+	q.mu.Unlock()
+	if e := closeOutput(q.sink); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Stats returns counters of queue
+func (q *QueueSink) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+// open recovers segment files and acknowledged position and opens active segment
+func (q *QueueSink) open() error {
+	paths, err := filepath.Glob(filepath.Join(q.dir, "*"+queueSegmentExt))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), queueSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, &queueSegment{seq: seq})
+	}
+	sort.Slice(q.segments, func(i, j int) bool {
+		return q.segments[i].seq < q.segments[j].seq
+	})
+	for _, segment := range q.segments {
+		if segment.size, err = recoverSegment(q.segmentPath(segment.seq)); err != nil {
+			return err
+		}
+		q.total += segment.size
+	}
+
+	if q.ack, err = os.OpenFile(filepath.Join(q.dir, queueAckFile), os.O_RDWR|os.O_CREATE, 0600); err != nil {
+		return err
+	}
+	ack := make([]byte, 16)
+	if _, err := q.ack.ReadAt(ack, 0); err == nil {
+		q.read = queuePosition{segment: binary.BigEndian.Uint64(ack), offset: int64(binary.BigEndian.Uint64(ack[8:]))}
+	}
+	if len(q.segments) == 0 {
+		seq := q.read.segment + 1
+		if err := q.createSegment(seq); err != nil {
+			return err
+		}
+		q.read = queuePosition{segment: seq}
+		return nil
+	}
+	first := q.segments[0]
+	if q.read.segment < first.seq {
+		q.read = queuePosition{segment: first.seq}
+	}
+	for q.read.segment > q.segments[0].seq && len(q.segments) > 1 {
+		q.removeSegment()
+	}
+	if q.read.segment != q.segments[0].seq || q.read.offset > q.segments[0].size {
+		q.read = queuePosition{segment: q.segments[0].seq, offset: q.segments[0].size}
+	}
+	last := q.segments[len(q.segments)-1]
+	q.active, err = os.OpenFile(q.segmentPath(last.seq), os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+// recoverSegment returns size of valid records of segment file and truncates records of interrupted writes
+func recoverSegment(path string) (int64, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	reader := bufio.NewReader(file)
+	var size int64
+	for {
+		_, n, err := readQueueRecord(reader)
+		if err != nil {
+			break
+		}
+		size += n
+	}
+	if size < info.Size() {
+		if err := file.Truncate(size); err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// readQueueRecord returns payload and size of next record, io.EOF returns at end of records
+func readQueueRecord(reader io.Reader) ([]byte, int64, error) {
+	header := make([]byte, queueHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, errQueueRecord
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, 0, errQueueRecord
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, errQueueRecord
+	}
+	return payload, int64(queueHeaderSize + len(payload)), nil
+}
+
+func (q *QueueSink) segmentPath(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, queueSegmentExt))
+}
+
+func (q *QueueSink) createSegment(seq uint64) error {
+	file, err := os.OpenFile(q.segmentPath(seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if q.active != nil {
+		_ = q.active.Sync()
+		_ = q.active.Close()
+	}
+	q.active = file
+	q.segments = append(q.segments, &queueSegment{seq: seq})
+	return nil
+}
+
+func (q *QueueSink) rotate() error {
+	return q.createSegment(q.segments[len(q.segments)-1].seq + 1)
+}
+
+// removeSegment deletes oldest segment and returns count of its records which are not delivered
+func (q *QueueSink) removeSegment() uint64 {
+	segment := q.segments[0]
+	var pending uint64
+	if q.read.segment <= segment.seq {
+		if file, err := os.Open(q.segmentPath(segment.seq)); err == nil {
+			reader := bufio.NewReader(io.NewSectionReader(file, q.read.offset, segment.size-q.read.offset))
+			for {
+				if _, _, err := readQueueRecord(reader); err != nil {
+					break
+				}
+				pending++
+			}
+			_ = file.Close()
+		}
+	}
+	_ = os.Remove(q.segmentPath(segment.seq))
+	q.total -= segment.size
+	q.segments = q.segments[1:]
+	if q.read.segment <= segment.seq {
+		q.read = queuePosition{segment: q.segments[0].seq}
+	}
+	return pending
+}
+
+// dropOldest deletes oldest segments until record of size fits in limit when policy is DropOldest
+func (q *QueueSink) dropOldest(size int64) bool {
+	if q.dropPolicy != DropOldest {
+		return false
+	}
+	for q.total+size > q.limit {
+		if len(q.segments) == 1 {
+			if q.segments[0].size == 0 {
+				return false
+			}
+			if err := q.rotate(); err != nil {
+				return false
+			}
+		}
+		q.stats.Dropped += q.removeSegment()
+	}
+	return true
+}
+
+func (q *QueueSink) run() {
+	defer close(q.stopped)
+	attempt := 0
+	for {
+		delivered, err := q.deliver()
+		if err != nil {
+			select {
+			case <-time.After(backoff(attempt, q.minBackoff, q.maxBackoff)):
+				attempt++
+				continue
+			case <-q.done:
+				return
+			}
+		}
+		attempt = 0
+		if delivered > 0 {
+			continue
+		}
+		select {
+		case <-q.notify:
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// deliver sends batch of records from read position on sink, flushes sink and acknowledges delivered records
+func (q *QueueSink) deliver() (int, error) {
+	if batch := q.spooled; batch != nil {
+		err := q.flush()
+		if errors.Is(err, ErrSpooled) {
+			return 0, err
+		}
+		q.spooled = nil
+		if err == nil {
+			q.acknowledge(batch.pos, batch.count, batch.dropped)
+			return batch.count, nil
+		}
+	}
+	q.mu.Lock()
+	for len(q.segments) > 1 && q.read.segment == q.segments[0].seq && q.read.offset >= q.segments[0].size {
+		q.removeSegment()
+	}
+	pos := q.read
+	end := q.segments[0].size
+	q.mu.Unlock()
+	if pos.offset >= end {
+		return 0, nil
+	}
+	file, err := os.Open(q.segmentPath(pos.segment))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(io.NewSectionReader(file, pos.offset, end-pos.offset))
+	count, dropped := 0, 0
+	for count < q.batch {
+		payload, n, err := readQueueRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		if err = decoder.Decode(&entry); err != nil {
+			sendFailed(entry, fmt.Errorf("log: queued entry can not decode: %v", err))
+			dropped++
+		} else if err = q.sink.Send(entry); err != nil {
+			if !queueRejected(err) {
+				if count == 0 {
+					return 0, err
+				}
+				break
+			}
+			sendFailed(entry, err)
+			dropped++
+		}
+		pos.offset += n
+		count++
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	if err := q.flush(); err != nil {
+		if errors.Is(err, ErrSpooled) {
+			q.spooled = &queueBatch{pos: pos, count: count, dropped: dropped}
+			return 0, err
+		}
+		if !queueRejected(err) {
+			return 0, err
+		}
+		dropped = count
+	}
+	q.acknowledge(pos, count, dropped)
+	return count, nil
+}
+
+// flush flushes sink when it supports flush
+func (q *QueueSink) flush() error {
+	if flusher, ok := q.sink.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// queueRejected returns true when sink rejects entries with 4xx status except 429 so redelivery can not succeed
+func queueRejected(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && !retryableStatus(status.Code)
+}
+
+// acknowledge persists position of delivered records unless their segment is dropped meanwhile
+func (q *QueueSink) acknowledge(pos queuePosition, count, dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if pos.segment != q.read.segment || pos.offset < q.read.offset {
+		return
+	}
+	q.read = pos
+	q.stats.Delivered += uint64(count - dropped)
+	q.stats.Dropped += uint64(dropped)
+	ack := make([]byte, 16)
+	binary.BigEndian.PutUint64(ack, pos.segment)
+	binary.BigEndian.PutUint64(ack[8:], uint64(pos.offset))
+	if _, err := q.ack.WriteAt(ack, 0); err == nil && q.syncPolicy == SyncAlways {
+		_ = q.ack.Sync()
+	}
+}
+
+func (q *QueueSink) syncPeriodically() {
+	ticker := time.NewTicker(q.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.mu.Lock()
+			select {
+			case <-q.stopped:
+			default:
+				_ = q.syncFiles()
+			}
+			q.mu.Unlock()
+		case <-q.stopped:
+			return
+		}
+	}
+}
+
+func (q *QueueSink) syncFiles() error {
+	if q.active == nil || q.ack == nil {
+		return nil
+	}
+	if err := q.active.Sync(); err != nil {
+		return err
+	}
+	return q.ack.Sync()
+}
+
+func (q *QueueSink) closeFiles() {
+	if q.active != nil {
+		_ = q.active.Close()
+		q.active = nil
+	}
+	if q.ack != nil {
+		_ = q.ack.Close()
+		q.ack = nil
+	}
+}
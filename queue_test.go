@@ -0,0 +1,311 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testQueueSink struct {
+	mu       sync.Mutex
+	messages []string
+	fail     bool
+	closed   bool
+}
+
+func (s *testQueueSink) Send(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	s.messages = append(s.messages, entry.Message)
+	return nil
+}
+
+func (s *testQueueSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *testQueueSink) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// waitTestMessages waits until sink receives count messages
+func waitTestMessages(t *testing.T, sink *testQueueSink, count int) []string {
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.received()) < count && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return sink.received()
+}
+
+func testMessages(from, to int) []string {
+	var messages []string
+	for i := from; i <= to; i++ {
+		messages = append(messages, fmt.Sprintf("message %d", i))
+	}
+	return messages
+}
+
+func testQueueDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(t, err)
+	return dir
+}
+
+func testSegments(t *testing.T, dir string) []string {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	assert.NoError(t, err)
+	return paths
+}
+
+func TestQueueSink_Send(t *testing.T) {
+	resetTest()
+	t.Run("must delivers entries in order and deletes delivered segments", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		sink := new(testQueueSink)
+		q, err := NewQueueSink(dir, sink, QueueSegmentSize(100), QueueSync(SyncAlways, 0))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 10)
+		assert.Equal(t, testMessages(1, 10), waitTestMessages(t, sink, 10))
+		assert.NoError(t, q.Close())
+		assert.True(t, sink.closed)
+		assert.Equal(t, QueueStats{Queued: 10, Delivered: 10}, q.Stats())
+		assert.Len(t, testSegments(t, dir), 1)
+	})
+	t.Run("must replays entries which are not acknowledged after restart", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		sink := new(testQueueSink)
+		q, err := NewQueueSink(dir, sink, QueueSegmentSize(200), QueueRetry(time.Millisecond, time.Millisecond))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 3)
+		assert.Equal(t, testMessages(1, 3), waitTestMessages(t, sink, 3))
+		sink.mu.Lock()
+		sink.fail = true
+		sink.mu.Unlock()
+		sendTestMessages(t, q, 4, 8)
+		assert.NoError(t, q.Close())
+
+		sink = new(testQueueSink)
+		q, err = NewQueueSink(dir, sink)
+		assert.NoError(t, err)
+		assert.Equal(t, testMessages(4, 8), waitTestMessages(t, sink, 5))
+		sendTestMessages(t, q, 9, 9)
+		assert.Equal(t, testMessages(4, 9), waitTestMessages(t, sink, 6))
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must recovers segment which is truncated mid-record", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		q, err := NewQueueSink(dir, &testQueueSink{fail: true}, QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 3)
+		assert.NoError(t, q.Close())
+		segments := testSegments(t, dir)
+		assert.Len(t, segments, 1)
+		info, err := os.Stat(segments[0])
+		assert.NoError(t, err)
+		assert.NoError(t, os.Truncate(segments[0], info.Size()-5))
+
+		sink := new(testQueueSink)
+		q, err = NewQueueSink(dir, sink)
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 4, 4)
+		assert.Equal(t, []string{"message 1", "message 2", "message 4"}, waitTestMessages(t, sink, 3))
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must recovers segment with corrupted record", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		q, err := NewQueueSink(dir, &testQueueSink{fail: true}, QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 3)
+		assert.NoError(t, q.Close())
+		segment := testSegments(t, dir)[0]
+		data, err := ioutil.ReadFile(segment)
+		assert.NoError(t, err)
+		data[len(data)/2] ^= 0xff
+		assert.NoError(t, ioutil.WriteFile(segment, data, 0600))
+
+		sink := new(testQueueSink)
+		q, err = NewQueueSink(dir, sink)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"message 1"}, waitTestMessages(t, sink, 1))
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must rejects new entries when limit is reached with drop newest", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		q, err := NewQueueSink(dir, &testQueueSink{fail: true}, QueueLimit(300, DropNewest), QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		var rejected int
+		for i := 0; i < 10; i++ {
+			if err := q.Send(Entry{Message: "message"}); err != nil {
+				assert.Equal(t, ErrQueueFull, err)
+				rejected++
+			}
+		}
+		assert.True(t, rejected > 0)
+		assert.Equal(t, uint64(rejected), q.Stats().Dropped)
+		assert.Equal(t, uint64(10-rejected), q.Stats().Queued)
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must deletes oldest segments when limit is reached with drop oldest", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		q, err := NewQueueSink(dir, &testQueueSink{fail: true}, QueueLimit(400, DropOldest), QueueSegmentSize(150),
+			QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 10)
+		stats := q.Stats()
+		assert.NoError(t, q.Close())
+		assert.Equal(t, uint64(10), stats.Queued)
+		assert.True(t, stats.Dropped > 0)
+
+		sink := new(testQueueSink)
+		q, err = NewQueueSink(dir, sink)
+		assert.NoError(t, err)
+		remaining := int(10 - stats.Dropped)
+		assert.Equal(t, testMessages(11-remaining, 10), waitTestMessages(t, sink, remaining))
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must redelivers entries until batching sink pushes them", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		var calls, failing uint64 = 0, 1
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddUint64(&calls, 1)
+			if atomic.LoadUint64(&failing) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		sink := NewLokiSink(server.URL, LokiRetry(0, time.Millisecond, time.Millisecond))
+		q, err := NewQueueSink(dir, sink, QueueRetry(time.Millisecond, 10*time.Millisecond))
+		assert.NoError(t, err)
+		assert.NoError(t, q.Send(Entry{Message: "message"}))
+		assert.Eventually(t, func() bool {
+			return atomic.LoadUint64(&calls) >= 3
+		}, 2*time.Second, time.Millisecond)
+		assert.Equal(t, QueueStats{Queued: 1}, q.Stats())
+		atomic.StoreUint64(&failing, 0)
+		assert.Eventually(t, func() bool {
+			return q.Stats().Delivered == 1
+		}, 2*time.Second, time.Millisecond)
+		assert.NoError(t, q.Close())
+		assert.Equal(t, QueueStats{Queued: 1, Delivered: 1}, q.Stats())
+	})
+	t.Run("must acknowledges entries of network sink when endpoint receives them", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		address := listener.Addr().String()
+		_ = listener.Close()
+		start := func() *QueueSink {
+			sink, err := NewNetworkSink("tcp", address, NetworkFormatter(testMessageFormatter(t)), NetworkBackoff(10*time.Millisecond, 10*time.Millisecond))
+			assert.NoError(t, err)
+			q, err := NewQueueSink(dir, sink, QueueRetry(10*time.Millisecond, 10*time.Millisecond))
+			assert.NoError(t, err)
+			return q
+		}
+		q := start()
+		sendTestMessages(t, q, 1, 3)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, ErrSpooled, q.Close())
+		assert.Equal(t, QueueStats{Queued: 3}, q.Stats())
+
+		server := startTestNetworkServer(t, address, nil)
+		defer server.stop()
+		q = start()
+		assert.Equal(t, testMessages(1, 3), server.receive(t, 3))
+		assert.Eventually(t, func() bool {
+			return q.Stats().Delivered == 3
+		}, 2*time.Second, time.Millisecond)
+		server.stop()
+		time.Sleep(50 * time.Millisecond)
+		sendTestMessages(t, q, 4, 5)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, uint64(3), q.Stats().Delivered)
+		server = startTestNetworkServer(t, address, nil)
+		defer server.stop()
+		assert.Equal(t, testMessages(4, 5), server.receive(t, 2))
+		assert.Eventually(t, func() bool {
+			return q.Stats().Delivered == 5
+		}, 2*time.Second, time.Millisecond)
+		select {
+		case line := <-server.lines:
+			t.Fatalf("entry is delivered again: %v", line)
+		case <-time.After(100 * time.Millisecond):
+		}
+		assert.NoError(t, q.Close())
+	})
+	t.Run("must drops entries which sink rejects", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+		q, err := NewQueueSink(dir, NewLokiSink(server.URL), QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 2)
+		assert.Eventually(t, func() bool {
+			return q.Stats().Dropped == 2
+		}, 2*time.Second, time.Millisecond)
+		assert.NoError(t, q.Close())
+		assert.Equal(t, QueueStats{Queued: 2, Dropped: 2}, q.Stats())
+	})
+	t.Run("must reports and drops entries which can not decode", func(t *testing.T) {
+		dir := testQueueDir(t)
+		defer os.RemoveAll(dir)
+		q, err := NewQueueSink(dir, &testQueueSink{fail: true}, QueueRetry(time.Hour, time.Hour))
+		assert.NoError(t, err)
+		sendTestMessages(t, q, 1, 1)
+		assert.NoError(t, q.Close())
+		payload := []byte(`{"Message":1}`)
+		record := make([]byte, 8, 8+len(payload))
+		binary.BigEndian.PutUint32(record, uint32(len(payload)))
+		binary.BigEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+		file, err := os.OpenFile(testSegments(t, dir)[0], os.O_WRONLY|os.O_APPEND, 0600)
+		assert.NoError(t, err)
+		_, err = file.Write(append(record, payload...))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		var failures []error
+		SetErrorHandler(func(entry Entry, err error) {
+			failures = append(failures, err)
+		})
+		defer SetErrorHandler(nil)
+		sink := new(testQueueSink)
+		q, err = NewQueueSink(dir, sink)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"message 1"}, waitTestMessages(t, sink, 1))
+		assert.NoError(t, q.Close())
+		assert.Equal(t, QueueStats{Delivered: 1, Dropped: 1}, q.Stats())
+		assert.Len(t, failures, 1)
+	})
+}
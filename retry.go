@@ -0,0 +1,208 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// RetryOption type of retry sink and retry writer option
+type RetryOption func(*retrier)
+
+// RetryAttempts sets count of retries after first failure (default: 5)
+func RetryAttempts(retries int) RetryOption {
+	return func(r *retrier) {
+		r.retries = retries
+	}
+}
+
+// RetryBackoff sets exponential backoff delays of retries, delays have jitter of half of delay (default: 100ms, 10s)
+func RetryBackoff(min, max time.Duration) RetryOption {
+	return func(r *retrier) {
+		r.min = min
+		r.max = max
+	}
+}
+
+// RetryClassifier sets classifier of errors which are retried (default: Retryable)
+func RetryClassifier(retryable func(error) bool) RetryOption {
+	return func(r *retrier) {
+		r.retryable = retryable
+	}
+}
+
+// Retryable returns true when error is temporary such as timeouts, closed or refused connections,
+// full queues and 429 or 5xx status errors
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return retryableStatus(status.Code)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	for _, temporary := range []error{
+		ErrQueueFull, io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe, context.DeadlineExceeded,
+		syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE,
+	} {
+		if errors.Is(err, temporary) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryStats keeps counters of retry sink and retry writer
+type RetryStats struct {
+	// Calls keeps count of calls
+	Calls uint64
+
+	// Retries keeps count of retries
+	Retries uint64
+
+	// Failures keeps count of calls which are failed after retries
+	Failures uint64
+}
+
+type retrier struct {
+	retries   int
+	min       time.Duration
+	max       time.Duration
+	retryable func(error) bool
+	stats     RetryStats
+}
+
+func newRetrier(options []RetryOption) *retrier {
+	r := &retrier{retries: 5, min: 100 * time.Millisecond, max: 10 * time.Second, retryable: Retryable}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// do calls fn until it succeeds, fails with error which is not retryable or retries are exhausted
+func (r *retrier) do(fn func() error) error {
+	atomic.AddUint64(&r.stats.Calls, 1)
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= r.retries || !r.retryable(err) {
+			atomic.AddUint64(&r.stats.Failures, 1)
+			return err
+		}
+		atomic.AddUint64(&r.stats.Retries, 1)
+		time.Sleep(backoff(attempt, r.min, r.max))
+	}
+}
+
+func (r *retrier) load() RetryStats {
+	return RetryStats{
+		Calls:    atomic.LoadUint64(&r.stats.Calls),
+		Retries:  atomic.LoadUint64(&r.stats.Retries),
+		Failures: atomic.LoadUint64(&r.stats.Failures),
+	}
+}
+
+// RetrySink implements sink which retries failed sends of sink with backoff, sends block while retrying
+// so retry sink is used behind batch sink on logging path
+type RetrySink struct {
+	sink    Sink
+	retrier *retrier
+}
+
+// NewRetrySink returns new retry sink in front of sink
+func NewRetrySink(sink Sink, options ...RetryOption) *RetrySink {
+	return &RetrySink{sink: sink, retrier: newRetrier(options)}
+}
+
+// Send sends entry on sink with retries
+func (s *RetrySink) Send(entry Entry) error {
+	return s.retrier.do(func() error {
+		return s.sink.Send(entry)
+	})
+}
+
+// SendBatch sends batch of entries on sink with retries, entries are sent one by one when sink does not
+// implement BatchSender and only failed entry is retried
+func (s *RetrySink) SendBatch(entries []Entry) error {
+	if sender, ok := s.sink.(BatchSender); ok {
+		return s.retrier.do(func() error {
+			return sender.SendBatch(entries)
+		})
+	}
+	for _, entry := range entries {
+		if err := s.Send(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes sink
+func (s *RetrySink) Flush() error {
+	if flusher, ok := s.sink.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes sink
+func (s *RetrySink) Close() error {
+	return closeOutput(s.sink)
+}
+
+// Stats returns counters of sink
+func (s *RetrySink) Stats() RetryStats {
+	return s.retrier.load()
+}
+
+// RetryWriter implements writer which retries failed writes of writer with backoff,
+// only bytes which are not written are retried
+type RetryWriter struct {
+	writer  io.Writer
+	retrier *retrier
+}
+
+// NewRetryWriter returns new retry writer in front of writer
+func NewRetryWriter(w io.Writer, options ...RetryOption) *RetryWriter {
+	return &RetryWriter{writer: w, retrier: newRetrier(options)}
+}
+
+// Write writes p on writer with retries
+func (w *RetryWriter) Write(p []byte) (int, error) {
+	written := 0
+	err := w.retrier.do(func() error {
+		n, err := w.writer.Write(p[written:])
+		written += n
+		return err
+	})
+	return written, err
+}
+
+// Flush flushes writer
+func (w *RetryWriter) Flush() error {
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes writer
+func (w *RetryWriter) Close() error {
+	return closeOutput(w.writer)
+}
+
+// Stats returns counters of writer
+func (w *RetryWriter) Stats() RetryStats {
+	return w.retrier.load()
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "must retries refused connection",
+			err:  &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED},
+			want: true,
+		},
+		{
+			name: "must retries wrapped end of file",
+			err:  fmt.Errorf("read: %w", io.EOF),
+			want: true,
+		},
+		{
+			name: "must retries full queue",
+			err:  ErrQueueFull,
+			want: true,
+		},
+		{
+			name: "must retries server error status",
+			err:  &StatusError{Code: 503},
+			want: true,
+		},
+		{
+			name: "must not retries client error status",
+			err:  &StatusError{Code: 400},
+		},
+		{
+			name: "must not retries open circuit",
+			err:  ErrCircuitOpen,
+		},
+		{
+			name: "must not retries canceled context",
+			err:  context.Canceled,
+		},
+		{
+			name: "must not retries unknown error",
+			err:  errors.New("invalid"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Retryable(tt.err))
+		})
+	}
+}
+
+func TestRetrySink_Send(t *testing.T) {
+	t.Run("must retries retryable failures until success", func(t *testing.T) {
+		failures := 2
+		inner := new(testQueueSink)
+		sink := NewRetrySink(testSinkFunc(func(entry Entry) error {
+			if failures > 0 {
+				failures--
+				return io.EOF
+			}
+			return inner.Send(entry)
+		}), RetryBackoff(time.Millisecond, time.Millisecond))
+		assert.NoError(t, sink.Send(Entry{Message: "message"}))
+		assert.Equal(t, []string{"message"}, inner.received())
+		assert.Equal(t, RetryStats{Calls: 1, Retries: 2}, sink.Stats())
+	})
+	t.Run("must returns error after retries", func(t *testing.T) {
+		sink := NewRetrySink(testSinkFunc(func(entry Entry) error {
+			return io.EOF
+		}), RetryAttempts(3), RetryBackoff(0, 0))
+		assert.Equal(t, io.EOF, sink.Send(Entry{}))
+		assert.Equal(t, RetryStats{Calls: 1, Retries: 3, Failures: 1}, sink.Stats())
+	})
+	t.Run("must not retries failures which are not retryable by classifier", func(t *testing.T) {
+		sink := NewRetrySink(testSinkFunc(func(entry Entry) error {
+			return io.EOF
+		}), RetryClassifier(func(error) bool { return false }))
+		assert.Equal(t, io.EOF, sink.Send(Entry{}))
+		assert.Equal(t, RetryStats{Calls: 1, Failures: 1}, sink.Stats())
+	})
+	t.Run("must sends batch on batch sender", func(t *testing.T) {
+		sender := new(testBatchSender)
+		sink := NewRetrySink(sender)
+		assert.NoError(t, sink.SendBatch([]Entry{{}, {}}))
+		assert.Equal(t, []int{2}, sender.sizes())
+	})
+}
+
+func TestRetryWriter_Write(t *testing.T) {
+	var written []byte
+	calls := 0
+	w := NewRetryWriter(testWriterFunc(func(p []byte) (int, error) {
+		calls++
+		if calls == 1 {
+			written = append(written, p[:2]...)
+			return 2, syscall.EPIPE
+		}
+		written = append(written, p...)
+		return len(p), nil
+	}), RetryBackoff(0, 0))
+	n, err := w.Write([]byte("message"))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, n)
+	assert.Equal(t, "message", string(written))
+	assert.Equal(t, RetryStats{Calls: 1, Retries: 1}, w.Stats())
+}
+
+type testSinkFunc func(entry Entry) error
+
+func (fn testSinkFunc) Send(entry Entry) error {
+	return fn(entry)
+}
@@ -0,0 +1,233 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ringSubscriberBuffer = 100
+
+// RingSink implements sink which retains last entries in memory, it is added with lower level than output
+// to keep debug entries for live debugging without writing them
+type RingSink struct {
+	mu          sync.RWMutex
+	entries     []Entry
+	next        int
+	full        bool
+	subscribers map[chan Entry]struct{}
+}
+
+// NewRingSink returns new ring sink which retains last size entries
+func NewRingSink(size int) *RingSink {
+	if size < 1 {
+		size = 1
+	}
+	return &RingSink{
+		entries:     make([]Entry, size),
+		subscribers: make(map[chan Entry]struct{}),
+	}
+}
+
+// Send retains entry in place of oldest entry and streams it to subscribers, slow subscribers miss entries
+func (s *RingSink) Send(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[s.next] = entry
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- entry:
+		default:
+		}
+	}
+	return nil
+}
+
+// Entries returns retained entries from oldest to newest
+func (s *RingSink) Entries() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.full {
+		return append([]Entry(nil), s.entries[:s.next]...)
+	}
+	return append(append(make([]Entry, 0, len(s.entries)), s.entries[s.next:]...), s.entries[:s.next]...)
+}
+
+func (s *RingSink) subscribe() chan Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscriber := make(chan Entry, ringSubscriberBuffer)
+	s.subscribers[subscriber] = struct{}{}
+	return subscriber
+}
+
+func (s *RingSink) unsubscribe(subscriber chan Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, subscriber)
+}
+
+// Handler returns http handler which serves retained entries as json array or text with format=text,
+// paths which end with /stream stream new entries as server-sent events
+//
+// Query parameters:
+//
+//	level=warning       entries equal or greater than level
+//	since, until        entries raised in range of rfc3339 times
+//	q=text              entries which message contains text
+//	field=key:value     entries which data has value of dotted key, it is repeatable
+//	limit=n             last n entries
+//	format=json|text    format of entries (default: json)
+func (s *RingSink) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseRingFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/stream") {
+			s.stream(w, r, filter)
+			return
+		}
+		var matched []string
+		for _, entry := range s.Entries() {
+			if filter.match(entry) {
+				matched = append(matched, filter.formatter.Format(entry))
+			}
+		}
+		if filter.limit > 0 && len(matched) > filter.limit {
+			matched = matched[len(matched)-filter.limit:]
+		}
+		if filter.text {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			for _, line := range matched {
+				_, _ = fmt.Fprintln(w, line)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, "[%s]\n", strings.Join(matched, ","))
+	})
+}
+
+// stream writes new entries which match filter as server-sent events until request is done
+func (s *RingSink) stream(w http.ResponseWriter, r *http.Request, filter *ringFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	subscriber := s.subscribe()
+	defer s.unsubscribe(subscriber)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case entry := <-subscriber:
+			if !filter.match(entry) {
+				continue
+			}
+			for _, line := range strings.Split(filter.formatter.Format(entry), "\n") {
+				_, _ = fmt.Fprintf(w, "data: %s\n", line)
+			}
+			_, _ = fmt.Fprint(w, "\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+type ringFilter struct {
+	level     Level
+	since     time.Time
+	until     time.Time
+	query     string
+	fields    map[string]string
+	limit     int
+	text      bool
+	formatter Formatter
+}
+
+func parseRingFilter(r *http.Request) (*ringFilter, error) {
+	query := r.URL.Query()
+	filter := &ringFilter{fields: make(map[string]string), formatter: NewJSONFormatter(JSONLevelString())}
+	if value := query.Get("level"); value != "" {
+		lvl, ok := parseLevel(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid level %q", value)
+		}
+		filter.level = lvl
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.since, "until": &filter.until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*t = parsed
+		}
+	}
+	filter.query = query.Get("q")
+	for _, value := range query["field"] {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid field %q", value)
+		}
+		filter.fields[parts[0]] = parts[1]
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit %q", value)
+		}
+		filter.limit = limit
+	}
+	switch format := query.Get("format"); format {
+	case "", "json":
+	case "text":
+		filter.text = true
+		filter.formatter = NewTextFormatter(TextColors(ColorNever))
+	default:
+		return nil, fmt.Errorf("invalid format %q", format)
+	}
+	return filter, nil
+}
+
+func (f *ringFilter) match(entry Entry) bool {
+	if entry.Level < f.level ||
+		(!f.since.IsZero() && entry.Raised.Before(f.since)) ||
+		(!f.until.IsZero() && entry.Raised.After(f.until)) ||
+		!strings.Contains(entry.Message, f.query) {
+		return false
+	}
+	if len(f.fields) == 0 {
+		return true
+	}
+	matched := 0
+	for _, field := range flattenData(entry.Data) {
+		if value, ok := f.fields[field.key]; ok && value == field.value {
+			matched++
+		}
+	}
+	return matched == len(f.fields)
+}
+
+// parseLevel returns level of case insensitive name or number
+func parseLevel(name string) (Level, bool) {
+	for _, lvl := range []Level{LevelDebug, LevelInfo, LevelWarning, LevelError, LevelFatal} {
+		if strings.EqualFold(name, lvl.String()) || name == strconv.Itoa(int(lvl)) {
+			return lvl, true
+		}
+	}
+	return 0, false
+}
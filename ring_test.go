@@ -0,0 +1,165 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRingEntries() []Entry {
+	raised := time.Date(2020, 4, 10, 12, 0, 0, 0, time.UTC)
+	return []Entry{
+		{Raised: raised, Level: LevelDebug, Message: "cache miss", Data: map[string]interface{}{"user": "a"}},
+		{Raised: raised.Add(time.Minute), Level: LevelInfo, Message: "request received", Data: map[string]interface{}{"user": "b"}},
+		{Raised: raised.Add(2 * time.Minute), Level: LevelWarning, Message: "request slow", Data: map[string]interface{}{"db": map[string]interface{}{"table": "users"}}},
+		{Raised: raised.Add(3 * time.Minute), Level: LevelError, Message: "request failed", Data: map[string]interface{}{"user": "a", "code": 500}},
+	}
+}
+
+func TestRingSink_Entries(t *testing.T) {
+	t.Run("must returns entries from oldest to newest", func(t *testing.T) {
+		sink := NewRingSink(3)
+		assert.Empty(t, sink.Entries())
+		for i, entry := range testRingEntries()[:2] {
+			assert.NoError(t, sink.Send(entry))
+			assert.Len(t, sink.Entries(), i+1)
+		}
+		assert.Equal(t, testRingEntries()[:2], sink.Entries())
+	})
+	t.Run("must overwrites oldest entries when it is full", func(t *testing.T) {
+		sink := NewRingSink(3)
+		for _, entry := range testRingEntries() {
+			assert.NoError(t, sink.Send(entry))
+		}
+		assert.Equal(t, testRingEntries()[1:], sink.Entries())
+	})
+}
+
+func TestRingSink_Handler(t *testing.T) {
+	sink := NewRingSink(10)
+	for _, entry := range testRingEntries() {
+		assert.NoError(t, sink.Send(entry))
+	}
+	server := httptest.NewServer(sink.Handler())
+	defer server.Close()
+	get := func(query string) (int, string) {
+		res, err := http.Get(server.URL + "/logs?" + query)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+	messages := func(body string) []string {
+		var values []map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &values))
+		messages := []string{}
+		for _, value := range values {
+			messages = append(messages, value["Message"].(string))
+		}
+		return messages
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"must returns all entries", "", []string{"cache miss", "request received", "request slow", "request failed"}},
+		{"must filters entries by level name", "level=warning", []string{"request slow", "request failed"}},
+		{"must filters entries by level number", "level=3", []string{"request failed"}},
+		{"must filters entries by time range", "since=2020-04-10T12:01:00Z&until=2020-04-10T12:02:00Z", []string{"request received", "request slow"}},
+		{"must filters entries by message", "q=request", []string{"request received", "request slow", "request failed"}},
+		{"must filters entries by fields", "field=user:a&field=code:500", []string{"request failed"}},
+		{"must filters entries by nested field", "field=db.table:users", []string{"request slow"}},
+		{"must returns last entries by limit", "q=request&limit=2", []string{"request slow", "request failed"}},
+		{"must returns empty array without matched entries", "q=unknown", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, body := get(test.query)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, test.want, messages(body))
+		})
+	}
+	t.Run("must returns entries as text", func(t *testing.T) {
+		status, body := get("format=text&level=error")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, NewTextFormatter(TextColors(ColorNever)).Format(testRingEntries()[3])+"\n", body)
+	})
+	t.Run("must returns bad request on invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"level=verbose", "since=yesterday", "field=user", "limit=-1", "format=xml"} {
+			status, _ := get(query)
+			assert.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+}
+
+func TestRingSink_stream(t *testing.T) {
+	t.Run("must streams new matched entries as server-sent events", func(t *testing.T) {
+		sink := NewRingSink(10)
+		server := httptest.NewServer(sink.Handler())
+		defer server.Close()
+		res, err := http.Get(server.URL + "/logs/stream?level=warning")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+		for _, entry := range testRingEntries() {
+			assert.NoError(t, sink.Send(entry))
+		}
+		reader := bufio.NewReader(res.Body)
+		var events []string
+		for len(events) < 2 {
+			line, err := reader.ReadString('\n')
+			assert.NoError(t, err)
+			if strings.HasPrefix(line, "data: ") {
+				var value map[string]interface{}
+				assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &value))
+				events = append(events, value["Message"].(string))
+			}
+		}
+		assert.Equal(t, []string{"request slow", "request failed"}, events)
+	})
+	t.Run("must unsubscribes when request is done", func(t *testing.T) {
+		sink := NewRingSink(10)
+		server := httptest.NewServer(sink.Handler())
+		defer server.Close()
+		res, err := http.Get(server.URL + "/stream")
+		assert.NoError(t, err)
+		sink.mu.RLock()
+		assert.Len(t, sink.subscribers, 1)
+		sink.mu.RUnlock()
+		_ = res.Body.Close()
+		assert.Eventually(t, func() bool {
+			sink.mu.RLock()
+			defer sink.mu.RUnlock()
+			return len(sink.subscribers) == 0
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func Test_parseLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Level
+		ok    bool
+	}{
+		{"must parses level name", "warning", LevelWarning, true},
+		{"must parses case insensitive level name", "DEBUG", LevelDebug, true},
+		{"must parses level number", "4", LevelFatal, true},
+		{"must returns false on unknown level", "verbose", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lvl, ok := parseLevel(test.value)
+			assert.Equal(t, test.want, lvl)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package log
+
+// Sink interface of entry receiver which receives logged entries beside output
+type Sink interface {
+	// Send sends logged entry on sink
+	Send(Entry) error
+}
+
+type levelSink struct {
+	sink  Sink
+	level Level
+}
+
+var sinks []levelSink
+
+// AddSink adds sink which receives entries equal or greater than level
+func AddSink(sink Sink, lvl Level) {
+	sinks = append(sinks, levelSink{sink: sink, level: lvl})
+}
+
+// enabled returns true when output or one of sinks receives level
+func enabled(lvl Level) bool {
+	if lvl >= level {
+		return true
+	}
+	for _, s := range sinks {
+		if lvl >= s.level {
+			return true
+		}
+	}
+	return false
+}
+
+func send(entry Entry) {
+	for _, s := range sinks {
+		if entry.Level >= s.level {
+			if err := s.sink.Send(entry); err != nil {
+				sendFailed(entry, err)
+			}
+		}
+	}
+}
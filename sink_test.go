@@ -0,0 +1,60 @@
+package log
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type testSink struct {
+	entries []Entry
+	err     error
+	closed  bool
+}
+
+func (s *testSink) Send(entry Entry) error {
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+func (s *testSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAddSink(t *testing.T) {
+	resetTest()
+	t.Run("must sends entries equal or greater than sink level", func(t *testing.T) {
+		SetLevel(LevelError)
+		defer SetLevel(LevelDebug)
+		sink := new(testSink)
+		AddSink(sink, LevelInfo)
+		Value("key", "value").Debug("debug message")
+		Info("info message")
+		Error("error message")
+		assert.Len(t, sink.entries, 2)
+		assert.Equal(t, "info message", sink.entries[0].Message)
+		assert.Equal(t, 1, strings.Count(testOutput.String(), "\n"))
+		testOutput.Reset()
+	})
+	t.Run("must handles failures of sink", func(t *testing.T) {
+		resetTest()
+		var handled error
+		SetErrorHandler(func(entry Entry, err error) {
+			handled = err
+		})
+		AddSink(&testSink{err: errors.New("unavailable")}, LevelDebug)
+		before := GetStats()
+		Info("info message")
+		assert.True(t, errors.Is(handled, ErrSend))
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+	t.Run("must closes sinks", func(t *testing.T) {
+		resetTest()
+		sink := new(testSink)
+		AddSink(sink, LevelDebug)
+		assert.NoError(t, Close())
+		assert.True(t, sink.closed)
+	})
+}
@@ -0,0 +1,299 @@
+package log
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrSplunkAck raises when events are not acknowledged by splunk in ack timeout
+var ErrSplunkAck = errors.New("log: events are not acknowledged by splunk")
+
+// splunkAckInterval keeps interval of ack polling
+var splunkAckInterval = 500 * time.Millisecond
+
+// SplunkOption type of splunk sink option
+type SplunkOption func(*SplunkSink)
+
+// SplunkSource sets source of events
+func SplunkSource(source string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.source = source
+	}
+}
+
+// SplunkSourceType sets source type of events (default: _json)
+func SplunkSourceType(sourceType string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.sourceType = sourceType
+	}
+}
+
+// SplunkIndex sets index of events, empty index uses default index of token
+func SplunkIndex(index string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.index = index
+	}
+}
+
+// SplunkHost sets host of events (default: os.Hostname)
+func SplunkHost(host string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.host = host
+	}
+}
+
+// SplunkFields sets data keys which are sent as indexed fields
+func SplunkFields(keys ...string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.fields = keys
+	}
+}
+
+// SplunkFormatter sets json formatter of events (default: json formatter with string levels)
+func SplunkFormatter(f Formatter) SplunkOption {
+	return func(s *SplunkSink) {
+		s.formatter = f
+	}
+}
+
+// SplunkAck enables indexer acknowledgement, events are resent when they are not acknowledged in timeout
+func SplunkAck(timeout time.Duration) SplunkOption {
+	return func(s *SplunkSink) {
+		s.ackTimeout = timeout
+	}
+}
+
+// SplunkChannel sets channel of requests (default: random channel)
+func SplunkChannel(channel string) SplunkOption {
+	return func(s *SplunkSink) {
+		s.channel = channel
+	}
+}
+
+// SplunkBatch sets maximum size and wait duration of batches (default: 100, 1s)
+func SplunkBatch(size int, wait time.Duration) SplunkOption {
+	return func(s *SplunkSink) {
+		s.size = size
+		s.wait = wait
+	}
+}
+
+// SplunkBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 10000)
+func SplunkBuffer(capacity int) SplunkOption {
+	return func(s *SplunkSink) {
+		s.capacity = capacity
+	}
+}
+
+// SplunkRetry sets retries of failed requests and their backoff delays (default: 5, 500ms, 30s)
+func SplunkRetry(retries int, min, max time.Duration) SplunkOption {
+	return func(s *SplunkSink) {
+		s.retry = httpRetry{retries: retries, min: min, max: max}
+	}
+}
+
+// SplunkClient sets http client of requests (default: client with 10s timeout)
+func SplunkClient(client *http.Client) SplunkOption {
+	return func(s *SplunkSink) {
+		s.client = client
+	}
+}
+
+// SplunkSink implements sink of splunk http event collector, entries are batched
+// and acknowledged by indexers optionally
+type SplunkSink struct {
+	url        string
+	token      string
+	source     string
+	sourceType string
+	index      string
+	host       string
+	fields     []string
+	formatter  Formatter
+	ackTimeout time.Duration
+	channel    string
+	size       int
+	wait       time.Duration
+	capacity   int
+	retry      httpRetry
+	client     *http.Client
+
+	batcher *batcher
+}
+
+// NewSplunkSink returns new splunk sink which sends on http event collector of url with token
+func NewSplunkSink(url, token string, options ...SplunkOption) *SplunkSink {
+	host, _ := os.Hostname()
+	s := &SplunkSink{
+		url:        strings.TrimRight(url, "/"),
+		token:      token,
+		sourceType: "_json",
+		host:       host,
+		formatter:  NewJSONFormatter(JSONLevelString()),
+		size:       100,
+		wait:       time.Second,
+		capacity:   10000,
+		retry:      httpRetry{retries: 5, min: 500 * time.Millisecond, max: 30 * time.Second},
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.formatter = sinkFormatter(s.formatter)
+	if s.channel == "" {
+		s.channel = splunkChannel()
+	}
+	s.batcher = newBatcher(s.size, s.wait, s.capacity, s.flush)
+	return s
+}
+
+// Send queues entry to send in next batch
+func (s *SplunkSink) Send(entry Entry) error {
+	return s.batcher.add(entry)
+}
+
+// Flush sends queued entries, it returns last error of failed entries since previous flush
+func (s *SplunkSink) Flush() error {
+	return s.batcher.sync()
+}
+
+// Close sends queued entries and stops sink, it returns last error of failed entries since previous flush
+func (s *SplunkSink) Close() error {
+	return s.batcher.close()
+}
+
+type splunkEvent struct {
+	Time       float64           `json:"time"`
+	Host       string            `json:"host,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	SourceType string            `json:"sourcetype,omitempty"`
+	Index      string            `json:"index,omitempty"`
+	Event      interface{}       `json:"event"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+// flush sends entries and resends them until they are acknowledged, entries which can not encode
+// are reported and skipped so error is returned only when encoded entries are not sent
+func (s *SplunkSink) flush(entries []Entry) error {
+	var body bytes.Buffer
+	encoded := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		event, err := json.Marshal(s.event(entry))
+		if err != nil {
+			sendFailed(entry, fmt.Errorf("%w: %v", ErrEncode, err))
+			continue
+		}
+		body.Write(event)
+		encoded = append(encoded, entry)
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+	payload := body.Bytes()
+	for attempt := 0; ; attempt++ {
+		err := s.post(payload)
+		if err == nil {
+			return nil
+		}
+		if err != ErrSplunkAck || attempt >= s.retry.retries {
+			for _, entry := range encoded {
+				sendFailed(entry, err)
+			}
+			return err
+		}
+		time.Sleep(backoff(attempt, s.retry.min, s.retry.max))
+	}
+}
+
+// post sends events and waits for acknowledgement when ack is enabled
+func (s *SplunkSink) post(payload []byte) error {
+	raw, err := s.retry.do(s.client, s.request("/services/collector/event", payload))
+	if err != nil || s.ackTimeout <= 0 {
+		return err
+	}
+	var res struct {
+		AckID *int64 `json:"ackId"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return err
+	}
+	if res.AckID == nil {
+		return fmt.Errorf("log: splunk response has no ack id: %s", raw)
+	}
+	query, _ := json.Marshal(map[string][]int64{"acks": {*res.AckID}})
+	deadline := time.Now().Add(s.ackTimeout)
+	for {
+		raw, err := s.retry.do(s.client, s.request("/services/collector/ack", query))
+		if err != nil {
+			return err
+		}
+		var acks struct {
+			Acks map[string]bool `json:"acks"`
+		}
+		if err := json.Unmarshal(raw, &acks); err != nil {
+			return err
+		}
+		if acks.Acks[fmt.Sprint(*res.AckID)] {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrSplunkAck
+		}
+		time.Sleep(splunkAckInterval)
+	}
+}
+
+func (s *SplunkSink) request(path string, payload []byte) func() (*http.Request, error) {
+	return func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.url+path, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Splunk "+s.token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Splunk-Request-Channel", s.channel)
+		return req, nil
+	}
+}
+
+func (s *SplunkSink) event(entry Entry) splunkEvent {
+	line := s.formatter.Format(entry)
+	var event interface{} = line
+	if json.Valid([]byte(line)) {
+		event = json.RawMessage(line)
+	}
+	var fields map[string]string
+	for _, key := range s.fields {
+		if value, ok := entry.Data[key]; ok {
+			if fields == nil {
+				fields = make(map[string]string, len(s.fields))
+			}
+			fields[key] = fmt.Sprint(value)
+		}
+	}
+	return splunkEvent{
+		Time:       float64(entry.Raised.UnixNano()/1e6) / 1e3,
+		Host:       s.host,
+		Source:     s.source,
+		SourceType: s.sourceType,
+		Index:      s.index,
+		Event:      event,
+		Fields:     fields,
+	}
+}
+
+// splunkChannel returns random uuid of request channel
+func splunkChannel() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSplunkEvent struct {
+	Time       float64                `json:"time"`
+	Host       string                 `json:"host"`
+	Source     string                 `json:"source"`
+	SourceType string                 `json:"sourcetype"`
+	Index      string                 `json:"index"`
+	Event      map[string]interface{} `json:"event"`
+	Fields     map[string]string      `json:"fields"`
+}
+
+// startTestSplunkServer emulates http event collector, ids of lost acks are never acknowledged
+func startTestSplunkServer(t *testing.T, lost ...int64) (*httptest.Server, <-chan testSplunkEvent) {
+	events := make(chan testSplunkEvent, 100)
+	var mu sync.Mutex
+	var ackID int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Splunk token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.NotEmpty(t, r.Header.Get("X-Splunk-Request-Channel"))
+		decoder := json.NewDecoder(r.Body)
+		switch r.URL.Path {
+		case "/services/collector/event":
+			mu.Lock()
+			id := ackID
+			ackID++
+			mu.Unlock()
+			for decoder.More() {
+				var event testSplunkEvent
+				assert.NoError(t, decoder.Decode(&event))
+				if !containsID(lost, id) {
+					events <- event
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"text": "Success", "code": 0, "ackId": id})
+		case "/services/collector/ack":
+			var query struct {
+				Acks []int64 `json:"acks"`
+			}
+			assert.NoError(t, decoder.Decode(&query))
+			acks := make(map[int64]bool)
+			for _, id := range query.Acks {
+				acks[id] = !containsID(lost, id)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"acks": acks})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, events
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, value := range ids {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSplunkSink_Send(t *testing.T) {
+	splunkAckInterval = time.Millisecond
+	entry := Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123456789, time.UTC),
+		Level:   LevelError,
+		Message: "text message",
+		Data:    map[string]interface{}{"key": "value", "user": 10},
+	}
+	receive := func(events <-chan testSplunkEvent) testSplunkEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("event is not received")
+			return testSplunkEvent{}
+		}
+	}
+	t.Run("must sends batched events with metadata", func(t *testing.T) {
+		server, events := startTestSplunkServer(t)
+		defer server.Close()
+		sink := NewSplunkSink(server.URL, "token", SplunkSource("app"), SplunkSourceType("log"), SplunkIndex("main"),
+			SplunkHost("host"), SplunkFields("user"), SplunkBatch(2, time.Hour))
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry))
+		assert.NoError(t, sink.Send(entry))
+		for i := 0; i < 2; i++ {
+			event := receive(events)
+			assert.Equal(t, 1586521845.123, event.Time)
+			assert.Equal(t, "host", event.Host)
+			assert.Equal(t, "app", event.Source)
+			assert.Equal(t, "log", event.SourceType)
+			assert.Equal(t, "main", event.Index)
+			assert.Equal(t, map[string]string{"user": "10"}, event.Fields)
+			assert.Equal(t, "text message", event.Event["Message"])
+			assert.Equal(t, "error", event.Event["Level"])
+		}
+	})
+	t.Run("must resends events which are not acknowledged", func(t *testing.T) {
+		server, events := startTestSplunkServer(t, 0)
+		defer server.Close()
+		sink := NewSplunkSink(server.URL, "token", SplunkAck(10*time.Millisecond),
+			SplunkRetry(2, time.Millisecond, time.Millisecond))
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry))
+		assert.NoError(t, sink.Flush())
+		assert.Equal(t, "text message", receive(events).Event["Message"])
+	})
+	t.Run("must reports failed entries on rejected token", func(t *testing.T) {
+		resetTest()
+		server, _ := startTestSplunkServer(t)
+		defer server.Close()
+		sink := NewSplunkSink(server.URL, "invalid")
+		before := GetStats()
+		assert.NoError(t, sink.Send(entry))
+		assert.Error(t, sink.Close())
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+}
+
+func Test_splunkChannel(t *testing.T) {
+	channel := splunkChannel()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), channel)
+	assert.NotEqual(t, channel, splunkChannel())
+}
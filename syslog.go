@@ -0,0 +1,251 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Facility type of syslog facility
+type Facility int
+
+const (
+	// FacilityKern kernel messages
+	FacilityKern Facility = iota
+	// FacilityUser user-level messages
+	FacilityUser
+	// FacilityMail mail system
+	FacilityMail
+	// FacilityDaemon system daemons
+	FacilityDaemon
+	// FacilityAuth security/authorization messages
+	FacilityAuth
+	// FacilitySyslog messages generated internally by syslogd
+	FacilitySyslog
+	// FacilityLPR line printer subsystem
+	FacilityLPR
+	// FacilityNews network news subsystem
+	FacilityNews
+	// FacilityUUCP UUCP subsystem
+	FacilityUUCP
+	// FacilityCron clock daemon
+	FacilityCron
+	// FacilityAuthPriv security/authorization messages
+	FacilityAuthPriv
+	// FacilityFTP FTP daemon
+	FacilityFTP
+)
+
+const (
+	// FacilityLocal0 local use 0
+	FacilityLocal0 Facility = iota + 16
+	// FacilityLocal1 local use 1
+	FacilityLocal1
+	// FacilityLocal2 local use 2
+	FacilityLocal2
+	// FacilityLocal3 local use 3
+	FacilityLocal3
+	// FacilityLocal4 local use 4
+	FacilityLocal4
+	// FacilityLocal5 local use 5
+	FacilityLocal5
+	// FacilityLocal6 local use 6
+	FacilityLocal6
+	// FacilityLocal7 local use 7
+	FacilityLocal7
+)
+
+const (
+	syslogNil        = "-"
+	syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+	syslogSDID       = "data@32473"
+)
+
+// SyslogOption type of syslog formatters option
+type SyslogOption func(*syslogConfig)
+
+// SyslogFacility sets facility of messages (default: FacilityUser)
+func SyslogFacility(facility Facility) SyslogOption {
+	return func(c *syslogConfig) {
+		c.facility = facility
+	}
+}
+
+// SyslogHostname sets hostname of messages (default: os.Hostname)
+func SyslogHostname(hostname string) SyslogOption {
+	return func(c *syslogConfig) {
+		c.hostname = hostname
+	}
+}
+
+// SyslogAppName sets app name or tag of messages (default: name of executable)
+func SyslogAppName(name string) SyslogOption {
+	return func(c *syslogConfig) {
+		c.appName = name
+	}
+}
+
+// SyslogProcID sets process id of messages (default: os.Getpid)
+func SyslogProcID(id string) SyslogOption {
+	return func(c *syslogConfig) {
+		c.procID = id
+	}
+}
+
+// SyslogMsgID sets rfc5424 message id of messages (default: -)
+func SyslogMsgID(id string) SyslogOption {
+	return func(c *syslogConfig) {
+		c.msgID = id
+	}
+}
+
+// SyslogSDID sets rfc5424 structured data id of entry data (default: data@32473)
+func SyslogSDID(id string) SyslogOption {
+	return func(c *syslogConfig) {
+		c.sdID = id
+	}
+}
+
+type syslogConfig struct {
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+	msgID    string
+	sdID     string
+}
+
+func newSyslogConfig(options []SyslogOption) syslogConfig {
+	hostname, _ := os.Hostname()
+	c := syslogConfig{
+		facility: FacilityUser,
+		hostname: hostname,
+		appName:  filepath.Base(os.Args[0]),
+		procID:   strconv.Itoa(os.Getpid()),
+		sdID:     syslogSDID,
+	}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+func (c syslogConfig) priority(lvl Level) int {
+	return int(c.facility)*8 + lvl.Severity()
+}
+
+// NewRFC5424Formatter returns new syslog formatter of rfc5424 messages, data is written as structured data
+func NewRFC5424Formatter(options ...SyslogOption) Formatter {
+	return &rfc5424Formatter{newSyslogConfig(options)}
+}
+
+type rfc5424Formatter struct {
+	syslogConfig
+}
+
+func (f rfc5424Formatter) Format(entry Entry) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		f.priority(entry.Level),
+		entry.Raised.Format(syslogTimeLayout),
+		syslogHeader(f.hostname, 255),
+		syslogHeader(f.appName, 48),
+		syslogHeader(f.procID, 128),
+		syslogHeader(f.msgID, 32),
+		f.structuredData(entry),
+		strings.TrimSpace(entry.Message),
+	)
+}
+
+func (f rfc5424Formatter) structuredData(entry Entry) string {
+	fields := flattenData(entry.Data)
+	if len(fields) == 0 {
+		return syslogNil
+	}
+	builder := new(strings.Builder)
+	builder.WriteByte('[')
+	builder.WriteString(syslogName(f.sdID))
+	for _, field := range fields {
+		builder.WriteByte(' ')
+		builder.WriteString(syslogName(field.key))
+		builder.WriteString(`="`)
+		builder.WriteString(sdEscaper.Replace(field.value))
+		builder.WriteByte('"')
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+// sdEscaper escapes characters of rfc5424 structured data param value
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogHeader returns printable ascii header field with max length or nil value when it is empty
+func syslogHeader(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	if value == "" {
+		return syslogNil
+	}
+	return value
+}
+
+// syslogName returns rfc5424 structured data name with max length of 32
+func syslogName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// NewRFC3164Formatter returns new syslog formatter of legacy rfc3164 messages, data is written after message as key=value
+func NewRFC3164Formatter(options ...SyslogOption) Formatter {
+	return &rfc3164Formatter{newSyslogConfig(options)}
+}
+
+type rfc3164Formatter struct {
+	syslogConfig
+}
+
+func (f rfc3164Formatter) Format(entry Entry) string {
+	tag := strings.Map(func(r rune) rune {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return -1
+		}
+		return r
+	}, f.appName)
+	if len(tag) > 32 {
+		tag = tag[:32]
+	}
+	if f.procID != "" {
+		tag = fmt.Sprintf("%s[%s]", tag, f.procID)
+	}
+	builder := new(strings.Builder)
+	for _, field := range flattenData(entry.Data) {
+		writeLogfmt(builder, field.key, field.value)
+	}
+	msg := strings.TrimSpace(entry.Message)
+	if builder.Len() > 0 {
+		msg = fmt.Sprintf("%s %s", msg, builder.String())
+	}
+	return fmt.Sprintf("<%d>%s %s %s: %s",
+		f.priority(entry.Level),
+		entry.Raised.Format("Jan _2 15:04:05"),
+		syslogHeader(f.hostname, 255),
+		tag,
+		msg,
+	)
+}
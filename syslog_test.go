@@ -0,0 +1,85 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func createTestSyslogEntry() Entry {
+	return Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 123456000, time.UTC),
+		Level:   LevelWarning,
+		Source:  "at test in test.go:10",
+		Message: "text message",
+		Data: map[string]interface{}{
+			"db":    Namespace{"query": `select "a]b" \ c`},
+			"key":   "value",
+			"a key": 1,
+		},
+	}
+}
+
+var testSyslogOptions = []SyslogOption{
+	SyslogFacility(FacilityLocal4),
+	SyslogHostname("host"),
+	SyslogAppName("my app"),
+	SyslogProcID("42"),
+}
+
+func TestNewRFC5424Formatter(t *testing.T) {
+	t.Run("must returns formatter with defaults", func(t *testing.T) {
+		hostname, _ := os.Hostname()
+		f := NewRFC5424Formatter().(*rfc5424Formatter)
+		assert.Equal(t, FacilityUser, f.facility)
+		assert.Equal(t, hostname, f.hostname)
+		assert.Equal(t, strconv.Itoa(os.Getpid()), f.procID)
+		assert.Equal(t, syslogSDID, f.sdID)
+		assert.NotEmpty(t, f.appName)
+	})
+}
+
+func Test_rfc5424Formatter_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []SyslogOption
+		entry   Entry
+		want    string
+	}{
+		{
+			name:    "must returns message with structured data",
+			options: append(testSyslogOptions, SyslogMsgID("ID47"), SyslogSDID("fields@123")),
+			entry:   createTestSyslogEntry(),
+			want:    `<164>1 2020-04-10T12:30:45.123456Z host my_app 42 ID47 [fields@123 a_key="1" db.query="select \"a\]b\" \\ c" key="value"] text message`,
+		},
+		{
+			name:    "must returns message with nil values",
+			options: []SyslogOption{SyslogFacility(FacilityDaemon), SyslogHostname(""), SyslogAppName(""), SyslogProcID("")},
+			entry:   Entry{Raised: time.Date(2020, 4, 10, 12, 30, 45, 0, time.UTC), Level: LevelFatal, Message: "fatal"},
+			want:    `<26>1 2020-04-10T12:30:45.000000Z - - - - - fatal`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewRFC5424Formatter(tt.options...).Format(tt.entry))
+		})
+	}
+}
+
+func TestNewRFC3164Formatter(t *testing.T) {
+	t.Run("must returns formatter with options", func(t *testing.T) {
+		f := NewRFC3164Formatter(SyslogFacility(FacilityLocal7)).(*rfc3164Formatter)
+		assert.Equal(t, FacilityLocal7, f.facility)
+	})
+}
+
+func Test_rfc3164Formatter_Format(t *testing.T) {
+	t.Run("must returns legacy message with data", func(t *testing.T) {
+		assert.Equal(t,
+			`<164>Apr 10 12:30:45 host myapp[42]: text message a_key=1 db.query="select \"a]b\" \\ c" key=value`,
+			NewRFC3164Formatter(testSyslogOptions...).Format(createTestSyslogEntry()),
+		)
+	})
+}
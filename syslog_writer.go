@@ -0,0 +1,142 @@
+package log
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSyslogUnavailable raises when local syslog daemon is not available
+var ErrSyslogUnavailable = errors.New("log: syslog daemon is not available")
+
+var syslogLocalAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogWriterOption type of syslog writer option
+type SyslogWriterOption func(*SyslogWriter)
+
+// SyslogWriterTLS sets tls config of tls network
+func SyslogWriterTLS(config *tls.Config) SyslogWriterOption {
+	return func(w *SyslogWriter) {
+		w.tlsConfig = config
+	}
+}
+
+// SyslogWriterTimeout sets timeout of dialing and writing (default: 5s)
+func SyslogWriterTimeout(timeout time.Duration) SyslogWriterOption {
+	return func(w *SyslogWriter) {
+		w.timeout = timeout
+	}
+}
+
+// SyslogWriter implements writer of formatted messages on syslog daemon, tcp and tls networks frame messages
+// with octet counting of rfc6587, unix stream sockets terminate messages with newline as local daemons expect
+// and failed connections are reconnected
+type SyslogWriter struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogWriter returns new syslog writer connected on network and address,
+// networks are udp, tcp, tls, unix and unixgram and empty network connects on local syslog daemon
+func NewSyslogWriter(network, address string, options ...SyslogWriterOption) (*SyslogWriter, error) {
+	w := &SyslogWriter{
+		network: network,
+		address: address,
+		timeout: 5 * time.Second,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes message on syslog daemon, message is reconnected and retried once on failure
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msg := w.frame(strings.TrimRight(string(p), "\n"))
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.connect(); err != nil {
+				continue
+			}
+		}
+		if err = w.write(msg); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+// Close closes connection of syslog daemon
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *SyslogWriter) write(msg string) error {
+	if w.timeout > 0 {
+		_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	_, err := w.conn.Write([]byte(msg))
+	return err
+}
+
+func (w *SyslogWriter) frame(msg string) string {
+	switch w.network {
+	case "tcp", "tcp4", "tcp6", "tls":
+		return fmt.Sprintf("%d %s", len(msg), msg)
+	case "unix":
+		return msg + "\n"
+	}
+	return msg
+}
+
+func (w *SyslogWriter) connect() (err error) {
+	dialer := &net.Dialer{Timeout: w.timeout}
+	switch w.network {
+	case "":
+		for _, address := range append([]string{w.address}, syslogLocalAddresses...) {
+			if address == "" {
+				continue
+			}
+			for _, network := range []string{"unixgram", "unix"} {
+				if w.conn, err = dialer.Dial(network, address); err == nil {
+					w.network = network
+					w.address = address
+					return nil
+				}
+			}
+		}
+		return ErrSyslogUnavailable
+	case "tls":
+		var conn *tls.Conn
+		if conn, err = tls.DialWithDialer(dialer, "tcp", w.address, w.tlsConfig); err == nil {
+			w.conn = conn
+		}
+	default:
+		w.conn, err = dialer.Dial(w.network, w.address)
+	}
+	return err
+}
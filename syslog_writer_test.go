@@ -0,0 +1,254 @@
+package log
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTLSConfigs returns server and client tls configs with self signed certificate of localhost
+func testTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: pool}
+}
+
+// startTestOctetServer accepts connections of listener and sends octet counting framed messages on channel
+func startTestOctetServer(listener net.Listener) <-chan string {
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					size, err := reader.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(size))
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(reader, msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+				}
+			}(conn)
+		}
+	}()
+	return messages
+}
+
+// startTestLineServer reads newline terminated messages of listener connections and sends them on channel
+func startTestLineServer(listener net.Listener) <-chan string {
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					messages <- scanner.Text()
+				}
+			}(conn)
+		}
+	}()
+	return messages
+}
+
+// startTestPacketServer reads datagrams of conn and sends them on channel
+func startTestPacketServer(conn net.PacketConn) <-chan string {
+	messages := make(chan string, 10)
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			messages <- string(buf[:n])
+		}
+	}()
+	return messages
+}
+
+func receiveTestMessage(t *testing.T, messages <-chan string) string {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("message is not received")
+		return ""
+	}
+}
+
+func TestNewSyslogWriter(t *testing.T) {
+	t.Run("must returns error when server is not available", func(t *testing.T) {
+		_, err := NewSyslogWriter("tcp", "127.0.0.1:1", SyslogWriterTimeout(time.Second))
+		assert.Error(t, err)
+	})
+	t.Run("must returns error when local daemon is not available", func(t *testing.T) {
+		defer func(addresses []string) { syslogLocalAddresses = addresses }(syslogLocalAddresses)
+		syslogLocalAddresses = []string{filepath.Join(os.TempDir(), "missing-syslog.sock")}
+		_, err := NewSyslogWriter("", "")
+		assert.Equal(t, ErrSyslogUnavailable, err)
+	})
+}
+
+func TestSyslogWriter_Write(t *testing.T) {
+	msg := "<14>1 2020-04-10T12:30:45.000000Z host app 1 - - text message"
+	t.Run("must writes datagram on udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer conn.Close()
+		messages := startTestPacketServer(conn)
+		w, err := NewSyslogWriter("udp", conn.LocalAddr().String())
+		assert.NoError(t, err)
+		defer w.Close()
+		n, err := w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, len(msg)+1, n)
+		assert.Equal(t, msg, receiveTestMessage(t, messages))
+	})
+	t.Run("must writes octet counting frames on tcp and reconnects", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+		messages := startTestOctetServer(listener)
+		w, err := NewSyslogWriter("tcp", listener.Addr().String())
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, receiveTestMessage(t, messages))
+		_ = w.conn.Close()
+		_, err = w.Write([]byte("second message\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "second message", receiveTestMessage(t, messages))
+	})
+	t.Run("must writes octet counting frames on tls", func(t *testing.T) {
+		server, client := testTLSConfigs(t)
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+		assert.NoError(t, err)
+		defer listener.Close()
+		messages := startTestOctetServer(listener)
+		w, err := NewSyslogWriter("tls", listener.Addr().String(), SyslogWriterTLS(client))
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, receiveTestMessage(t, messages))
+		_ = listener.Close()
+		_ = w.conn.Close()
+		w.conn = nil
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.Error(t, err)
+	})
+	t.Run("must writes datagram on local unix socket", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "syslog")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		address := filepath.Join(dir, "log.sock")
+		conn, err := net.ListenPacket("unixgram", address)
+		assert.NoError(t, err)
+		defer conn.Close()
+		messages := startTestPacketServer(conn)
+		defer func(addresses []string) { syslogLocalAddresses = addresses }(syslogLocalAddresses)
+		syslogLocalAddresses = []string{address}
+		w, err := NewSyslogWriter("", "")
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, receiveTestMessage(t, messages))
+	})
+	t.Run("must writes newline terminated messages on unix stream socket", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "syslog")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		listener, err := net.Listen("unix", filepath.Join(dir, "log.sock"))
+		assert.NoError(t, err)
+		defer listener.Close()
+		messages := startTestLineServer(listener)
+		w, err := NewSyslogWriter("unix", listener.Addr().String())
+		assert.NoError(t, err)
+		defer w.Close()
+		_, err = w.Write([]byte(msg + "\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, receiveTestMessage(t, messages))
+	})
+	t.Run("must writes newline terminated messages on local unix stream socket", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "syslog")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		address := filepath.Join(dir, "log.sock")
+		listener, err := net.Listen("unix", address)
+		assert.NoError(t, err)
+		defer listener.Close()
+		messages := startTestLineServer(listener)
+		defer func(addresses []string) { syslogLocalAddresses = addresses }(syslogLocalAddresses)
+		syslogLocalAddresses = []string{address}
+		w, err := NewSyslogWriter("", "")
+		assert.NoError(t, err)
+		defer w.Close()
+		for i := 0; i < 2; i++ {
+			_, err = w.Write([]byte(msg + "\n"))
+			assert.NoError(t, err)
+			assert.Equal(t, msg, receiveTestMessage(t, messages))
+		}
+	})
+}
+
+func TestSyslogWriter_Close(t *testing.T) {
+	t.Run("must closes connection", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer conn.Close()
+		w, err := NewSyslogWriter("udp", conn.LocalAddr().String())
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.Nil(t, w.conn)
+		assert.NoError(t, w.Close())
+	})
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NewTemplateFormatter returns new formatter which executes text/template with entry, template is parsed
+// so syntax mistakes and unknown functions return as error, execution errors are reported as encoding failures
+//
+// Template functions:
+//
+//	color level text    colors text with color of level when colors are enabled
+//	pad width value     pads value with spaces on right side to width (negative width pads on left side)
+//	time layout t       formats time with layout
+//	upper, lower, trim  changes case or trims spaces of value
+//	field data key      returns value of dotted key in data
+//	fields data keys... returns selected keys of data as key=value (all keys when no key is given)
+//	omit data keys...   returns data except keys as key=value
+//	json value          returns json marshal of value
+func NewTemplateFormatter(text string) (Formatter, error) {
+	colors := newTextFormatter()
+	tmpl, err := template.New("entry").Funcs(templateFuncs(colors)).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &templateFormatter{tmpl: tmpl, colors: colors}, nil
+}
+
+type templateFormatter struct {
+	tmpl   *template.Template
+	colors *textFormatter
+}
+
+// plain returns copy of formatter which never colors when it colors automatically
+func (f *templateFormatter) plain() *templateFormatter {
+	colors := f.colors.plain()
+	if colors == f.colors {
+		return f
+	}
+	return &templateFormatter{tmpl: template.Must(f.tmpl.Clone()).Funcs(templateFuncs(colors)), colors: colors}
+}
+
+func (f templateFormatter) Format(entry Entry) string {
+	builder := new(strings.Builder)
+	if err := f.tmpl.Execute(builder, entry); err != nil {
+		builder.Reset()
+		if err := f.tmpl.Execute(builder, encodeFailed(entry, err)); err != nil {
+			return strings.TrimSpace(entry.Message)
+		}
+	}
+	return builder.String()
+}
+
+// templateFuncs returns template functions which color levels with colors of text formatter
+func templateFuncs(colors *textFormatter) template.FuncMap {
+	return template.FuncMap{
+		"color": func(lvl Level, value interface{}) string {
+			return colors.colorize(lvl, fmt.Sprint(value))
+		},
+		"pad": func(width int, value interface{}) string {
+			if width < 0 {
+				return fmt.Sprintf("%*s", -width, fmt.Sprint(value))
+			}
+			return fmt.Sprintf("%-*s", width, fmt.Sprint(value))
+		},
+		"time": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"upper": func(value interface{}) string {
+			return strings.ToUpper(fmt.Sprint(value))
+		},
+		"lower": func(value interface{}) string {
+			return strings.ToLower(fmt.Sprint(value))
+		},
+		"trim": func(value interface{}) string {
+			return strings.TrimSpace(fmt.Sprint(value))
+		},
+		"field": func(data map[string]interface{}, key string) string {
+			for _, f := range flattenData(data) {
+				if f.key == key {
+					return f.value
+				}
+			}
+			return ""
+		},
+		"fields": func(data map[string]interface{}, keys ...string) string {
+			return templateFields(data, func(key string) bool {
+				return len(keys) == 0 || matchesKey(keys, key)
+			})
+		},
+		"omit": func(data map[string]interface{}, keys ...string) string {
+			return templateFields(data, func(key string) bool {
+				return !matchesKey(keys, key)
+			})
+		},
+		"json": func(value interface{}) (string, error) {
+			bytes, err := json.Marshal(value)
+			return string(bytes), err
+		},
+	}
+}
+
+func templateFields(data map[string]interface{}, selected func(key string) bool) string {
+	builder := new(strings.Builder)
+	for _, f := range flattenData(data) {
+		if selected(f.key) {
+			writeLogfmt(builder, f.key, f.value)
+		}
+	}
+	return builder.String()
+}
+
+// matchesKey returns true when dotted key is one of keys or nested under one of them
+func matchesKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
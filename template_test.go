@@ -0,0 +1,128 @@
+package log
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewTemplateFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{
+			name: "must returns template formatter",
+			text: `{{time "15:04:05" .Raised}} {{.Level}} {{.Message}}`,
+		},
+		{
+			name:    "must returns error of invalid syntax",
+			text:    `{{.Message`,
+			wantErr: true,
+		},
+		{
+			name:    "must returns error of unknown function",
+			text:    `{{unknown .Message}}`,
+			wantErr: true,
+		},
+		{
+			name: "must returns template formatter of optional data",
+			text: `{{.Data.db.query}} {{if .Data.user}}{{.Data.user.name}}{{end}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTemplateFormatter(tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, f)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, f)
+		})
+	}
+}
+
+func Test_templateFormatter_Format(t *testing.T) {
+	resetTest()
+	entry := Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 0, time.UTC),
+		Level:   LevelWarning,
+		Source:  "at test in test.go:10",
+		Message: " text message ",
+		Data: map[string]interface{}{
+			"db":    Namespace{"query": "select 1"},
+			"key":   "value",
+			"other": 10,
+		},
+	}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "must returns entry fields",
+			text: `{{time "2006-01-02T15:04:05" .Raised}} [{{pad 7 (upper .Level)}}] {{trim .Message}} {{.Source}}`,
+			want: "2020-04-10T12:30:45 [WARNING] text message at test in test.go:10",
+		},
+		{
+			name: "must pads on left side with negative width",
+			text: `[{{pad -9 (upper .Level)}}] [{{pad -3 .Level}}]`,
+			want: "[  WARNING] [Warning]",
+		},
+		{
+			name: "must returns level without colors on buffer output",
+			text: `{{color .Level (lower .Level)}}`,
+			want: "warning",
+		},
+		{
+			name: "must returns selected fields",
+			text: `{{field .Data "db.query"}}|{{fields .Data "db" "key"}}|{{omit .Data "db"}}|{{fields .Data}}`,
+			want: `select 1|db.query="select 1" key=value|key=value other=10|db.query="select 1" key=value other=10`,
+		},
+		{
+			name: "must returns optional data",
+			text: `{{.Data.db.query}}{{if .Data.user}} {{.Data.user.name}}{{end}}`,
+			want: "select 1",
+		},
+		{
+			name: "must returns json of data",
+			text: `{{json .Data}}`,
+			want: `{"db":{"query":"select 1"},"key":"value","other":10}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTemplateFormatter(tt.text)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, f.Format(entry))
+		})
+	}
+	t.Run("must reports execution errors", func(t *testing.T) {
+		var failures []error
+		SetErrorHandler(func(entry Entry, err error) {
+			failures = append(failures, err)
+		})
+		defer SetErrorHandler(nil)
+		for _, text := range []string{`{{.Unknown}}`, `{{time .Raised}}`} {
+			f, err := NewTemplateFormatter(text)
+			assert.NoError(t, err)
+			assert.Equal(t, "text message", f.Format(entry))
+		}
+		assert.Len(t, failures, 2)
+		for _, err := range failures {
+			assert.True(t, errors.Is(err, ErrEncode))
+		}
+	})
+	t.Run("must returns degraded line when execution fails", func(t *testing.T) {
+		f, err := NewTemplateFormatter(`{{.Message}} {{json .Data}}`)
+		assert.NoError(t, err)
+		line := f.Format(Entry{Message: "text message", Data: map[string]interface{}{"channel": make(chan int)}})
+		assert.Contains(t, line, "text message")
+		assert.Contains(t, line, dataEncodeError)
+	})
+}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ColorMode type of text formatter coloring mode
+type ColorMode int
+
+const (
+	// ColorAuto colors levels when output is terminal, FORCE_COLOR and NO_COLOR environments are honoured
+	// on creation of formatter and formatters of sinks are not colored
+	ColorAuto ColorMode = iota
+
+	// ColorAlways always colors levels
+	ColorAlways
+
+	// ColorNever never colors levels
+	ColorNever
+)
+
+const (
+	textTimeLayout = "2006-01-02 15:04:05.000-07:00"
+	textLevelWidth = len("WARNING")
+)
+
+var textPalette = map[Level]string{
+	LevelFatal:   "1;31",
+	LevelError:   "0;31",
+	LevelWarning: "0;33",
+	LevelInfo:    "0;36",
+	LevelDebug:   "0;37",
+}
+
+// TextOption type of text formatter option
+type TextOption func(*textFormatter)
+
+// TextColors sets coloring mode of levels (default: ColorAuto)
+func TextColors(mode ColorMode) TextOption {
+	return func(f *textFormatter) {
+		f.colors = mode
+	}
+}
+
+// TextPalette sets ansi color codes of levels like "0;31", missing levels keep defaults
+func TextPalette(palette map[Level]string) TextOption {
+	return func(f *textFormatter) {
+		f.palette = palette
+	}
+}
+
+// TextTimeLayout sets layout of raised time (default: 2006-01-02 15:04:05.000-07:00)
+func TextTimeLayout(layout string) TextOption {
+	return func(f *textFormatter) {
+		f.layout = layout
+	}
+}
+
+// TextLocation sets timezone of raised time (default: time of entry)
+func TextLocation(loc *time.Location) TextOption {
+	return func(f *textFormatter) {
+		f.location = loc
+	}
+}
+
+// TextSingleLine writes source and data inline as key=value in same line
+func TextSingleLine() TextOption {
+	return func(f *textFormatter) {
+		f.singleLine = true
+	}
+}
+
+// TextSource sets writing source of entry (default: true)
+func TextSource(show bool) TextOption {
+	return func(f *textFormatter) {
+		f.hideSource = !show
+	}
+}
+
+func (f textFormatter) raised(entry Entry) string {
+	raised := entry.Raised
+	if f.location != nil {
+		raised = raised.In(f.location)
+	}
+	return raised.Format(keyOrDefault(f.layout, textTimeLayout))
+}
+
+func (f textFormatter) level(entry Entry) string {
+	return f.colorize(entry.Level, fmt.Sprintf("%-*s", textLevelWidth, strings.ToUpper(entry.Level.String())))
+}
+
+func (f textFormatter) colorize(lvl Level, text string) string {
+	if !f.colored() {
+		return text
+	}
+	color, ok := f.palette[lvl]
+	if !ok {
+		color = textPalette[lvl]
+	}
+	if color == "" {
+		return text
+	}
+	return fmt.Sprintf("\033[%sm%s\033[0m", color, text)
+}
+
+func (f textFormatter) colored() bool {
+	switch f.colors {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	return outputTerminal
+}
+
+// plain returns copy of formatter which never colors when it colors automatically
+func (f *textFormatter) plain() *textFormatter {
+	if f.colors != ColorAuto {
+		return f
+	}
+	plain := *f
+	plain.colors = ColorNever
+	return &plain
+}
+
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (f textFormatter) inline(entry Entry) string {
+	builder := new(strings.Builder)
+	for _, field := range flattenData(entry.Data) {
+		writeLogfmt(builder, field.key, field.value)
+	}
+	if !f.hideSource && entry.Source != "" {
+		writeLogfmt(builder, "source", entry.Source)
+	}
+	return builder.String()
+}
@@ -0,0 +1,132 @@
+package log
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_textFormatter_Format_options(t *testing.T) {
+	resetTest()
+	entry := Entry{
+		Raised:  time.Date(2020, 4, 10, 12, 30, 45, 100000000, time.UTC),
+		Level:   LevelInfo,
+		Source:  "at test in test.go:10",
+		Message: "text message",
+		Data: map[string]interface{}{
+			"db":  Namespace{"query": "select 1"},
+			"key": "value",
+		},
+	}
+	tests := []struct {
+		name    string
+		options []TextOption
+		want    string
+	}{
+		{
+			name: "must returns fixed width time and level",
+			want: "2020-04-10 12:30:45.100+00:00 | INFO    | text message \n\tat test in test.go:10\n\tdb.query: select 1\n\tkey: value",
+		},
+		{
+			name:    "must returns colored level",
+			options: []TextOption{TextColors(ColorAlways)},
+			want:    "2020-04-10 12:30:45.100+00:00 | \033[0;36mINFO   \033[0m | text message \n\tat test in test.go:10\n\tdb.query: select 1\n\tkey: value",
+		},
+		{
+			name:    "must returns colored level with palette",
+			options: []TextOption{TextColors(ColorAlways), TextPalette(map[Level]string{LevelInfo: "1;32"})},
+			want:    "2020-04-10 12:30:45.100+00:00 | \033[1;32mINFO   \033[0m | text message \n\tat test in test.go:10\n\tdb.query: select 1\n\tkey: value",
+		},
+		{
+			name:    "must returns time with layout and location",
+			options: []TextOption{TextTimeLayout(time.RFC3339), TextLocation(time.FixedZone("IRDT", 16200))},
+			want:    "2020-04-10T17:00:45+04:30 | INFO    | text message \n\tat test in test.go:10\n\tdb.query: select 1\n\tkey: value",
+		},
+		{
+			name:    "must returns without source",
+			options: []TextOption{TextSource(false)},
+			want:    "2020-04-10 12:30:45.100+00:00 | INFO    | text message\n\tdb.query: select 1\n\tkey: value",
+		},
+		{
+			name:    "must returns single line",
+			options: []TextOption{TextSingleLine()},
+			want:    `2020-04-10 12:30:45.100+00:00 | INFO    | text message db.query="select 1" key=value source="at test in test.go:10"`,
+		},
+		{
+			name:    "must returns single line without source",
+			options: []TextOption{TextSingleLine(), TextSource(false)},
+			want:    `2020-04-10 12:30:45.100+00:00 | INFO    | text message db.query="select 1" key=value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewTextFormatter(tt.options...).Format(entry))
+		})
+	}
+}
+
+func Test_textFormatter_colored(t *testing.T) {
+	resetTest()
+	tests := []struct {
+		name string
+		mode ColorMode
+		env  map[string]string
+		want bool
+	}{
+		{
+			name: "must not colors buffer output",
+			want: false,
+		},
+		{
+			name: "must colors with force color",
+			env:  map[string]string{"FORCE_COLOR": "1"},
+			want: true,
+		},
+		{
+			name: "must not colors with no color",
+			mode: ColorAuto,
+			env:  map[string]string{"NO_COLOR": "1"},
+			want: false,
+		},
+		{
+			name: "must colors with always mode",
+			mode: ColorAlways,
+			env:  map[string]string{"NO_COLOR": "1"},
+			want: true,
+		},
+		{
+			name: "must not colors with never mode",
+			mode: ColorNever,
+			env:  map[string]string{"FORCE_COLOR": "1"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				_ = os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.env {
+					_ = os.Unsetenv(key)
+				}
+			}()
+			assert.Equal(t, tt.want, newTextFormatter(TextColors(tt.mode)).colored())
+		})
+	}
+	t.Run("must colors terminal output", func(t *testing.T) {
+		defer resetTest()
+		f := newTextFormatter()
+		outputTerminal = true
+		assert.True(t, f.colored())
+	})
+	t.Run("must resolves environments on creation", func(t *testing.T) {
+		_ = os.Setenv("NO_COLOR", "1")
+		f := newTextFormatter()
+		_ = os.Unsetenv("NO_COLOR")
+		outputTerminal = true
+		defer resetTest()
+		assert.False(t, f.colored())
+	})
+}
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrTraceparent raises when traceparent header is not valid
+var ErrTraceparent = errors.New("log: invalid traceparent")
+
+const traceStateMembers = 32
+
+// TraceKeys keeps data keys of trace context, empty keys are not written
+type TraceKeys struct {
+	// TraceID keeps key of trace id (default: trace_id)
+	TraceID string
+
+	// SpanID keeps key of span id (default: span_id)
+	SpanID string
+
+	// Flags keeps key of trace flags (default: trace_flags)
+	Flags string
+
+	// State keeps key of trace state (default: empty)
+	State string
+}
+
+var traceKeys = defaultTraceKeys()
+
+func defaultTraceKeys() TraceKeys {
+	return TraceKeys{TraceID: "trace_id", SpanID: "span_id", Flags: "trace_flags"}
+}
+
+// SetTraceKeys sets data keys of trace context, formatters which map trace context read same keys
+func SetTraceKeys(keys TraceKeys) {
+	traceKeys = keys
+}
+
+// TraceContext keeps w3c trace context which links entries to span
+type TraceContext struct {
+	// TraceID keeps 32 lower hex digits of trace id
+	TraceID string
+
+	// SpanID keeps 16 lower hex digits of span id
+	SpanID string
+
+	// Flags keeps trace flags
+	Flags byte
+
+	// State keeps vendor specific trace state
+	State string
+}
+
+// ParseTraceparent returns trace context of traceparent and tracestate headers
+func ParseTraceparent(traceparent, tracestate string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || !lowerHex(parts[0]) ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, fmt.Errorf("%w: %q", ErrTraceparent, traceparent)
+	}
+	tc := TraceContext{TraceID: parts[1], SpanID: parts[2]}
+	if len(parts[3]) != 2 || !lowerHex(parts[3]) || !tc.Valid() {
+		return TraceContext{}, fmt.Errorf("%w: %q", ErrTraceparent, traceparent)
+	}
+	flags, _ := strconv.ParseUint(parts[3], 16, 8)
+	tc.Flags = byte(flags)
+	tc.State = parseTracestate(tracestate)
+	return tc, nil
+}
+
+// parseTracestate returns trimmed members of tracestate, empty members and members over limit are dropped
+func parseTracestate(tracestate string) string {
+	var members []string
+	for _, member := range strings.Split(tracestate, ",") {
+		if member = strings.TrimSpace(member); member != "" && strings.Contains(member, "=") {
+			members = append(members, member)
+		}
+	}
+	if len(members) > traceStateMembers {
+		members = members[:traceStateMembers]
+	}
+	return strings.Join(members, ",")
+}
+
+// Valid returns true when trace and span ids are valid and not zero
+func (tc TraceContext) Valid() bool {
+	return validID(tc.TraceID, 16) && validID(tc.SpanID, 8)
+}
+
+// Sampled returns true when sampled flag is set
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&1 == 1
+}
+
+// Traceparent returns traceparent header of trace context
+func (tc TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceID, tc.SpanID, tc.Flags)
+}
+
+func validID(id string, size int) bool {
+	return len(id) == size*2 && lowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+func lowerHex(text string) bool {
+	if _, err := hex.DecodeString(text); err != nil {
+		return false
+	}
+	return strings.ToLower(text) == text
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns child of context which carries trace context
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceFromContext returns trace context which is carried by context
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.Valid()
+}
+
+// traceFlags returns flags of trace flags data value
+func traceFlags(value interface{}) (byte, bool) {
+	text, ok := value.(string)
+	if !ok || len(text) != 2 {
+		return 0, false
+	}
+	flags, err := strconv.ParseUint(text, 16, 8)
+	return byte(flags), err == nil
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		want        TraceContext
+		wantErr     bool
+	}{
+		{
+			name:        "must parses sampled trace context",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			tracestate:  " rojo=00f067aa0ba902b7 ,, congo=t61rcWkgMzE",
+			want: TraceContext{
+				TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+				SpanID:  "00f067aa0ba902b7",
+				Flags:   1,
+				State:   "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE",
+			},
+		},
+		{
+			name:        "must parses future version with extra fields",
+			traceparent: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra",
+			want:        TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"},
+		},
+		{
+			name:        "must returns error with invalid version",
+			traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "must returns error with extra fields of version 00",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			wantErr:     true,
+		},
+		{
+			name:        "must returns error with zero trace id",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "must returns error with upper case span id",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00F067AA0BA902B7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "must returns error with invalid flags",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+			wantErr:     true,
+		},
+		{
+			name:        "must returns error with empty header",
+			traceparent: "",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceparent(tt.traceparent, tt.tracestate)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.True(t, strings.HasPrefix(err.Error(), ErrTraceparent.Error()))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTraceContext_Traceparent(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tc, err := ParseTraceparent(traceparent, "")
+	assert.NoError(t, err)
+	assert.Equal(t, traceparent, tc.Traceparent())
+	assert.True(t, tc.Sampled())
+	assert.False(t, TraceContext{Flags: 2}.Sampled())
+}
+
+func TestTraceFromContext(t *testing.T) {
+	tc := TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	got, ok := TraceFromContext(ContextWithTrace(context.Background(), tc))
+	assert.True(t, ok)
+	assert.Equal(t, tc, got)
+	_, ok = TraceFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = TraceFromContext(ContextWithTrace(context.Background(), TraceContext{}))
+	assert.False(t, ok)
+}
+
+func Test_parseTracestate(t *testing.T) {
+	members := make([]string, 40)
+	for i := range members {
+		members[i] = "k=v"
+	}
+	assert.Equal(t, strings.Join(members[:32], ","), parseTracestate(strings.Join(members, ",")))
+	assert.Equal(t, "", parseTracestate(" , invalid"))
+}
+
+func TestTrace(t *testing.T) {
+	resetTest()
+	tc := TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Flags: 1}
+	t.Run("must renders trace context in formatters", func(t *testing.T) {
+		SetFormatter(NewLogfmtFormatter())
+		defer SetFormatter(new(jsonFormatter))
+		Trace(tc).Info("text message")
+		assert.Contains(t, testOutput.String(), "span_id=00f067aa0ba902b7 trace_flags=01 trace_id=4bf92f3577b34da6a3ce929d0e0e4736")
+		testOutput.Reset()
+	})
+	t.Run("must maps configured trace keys in provider formatters", func(t *testing.T) {
+		SetTraceKeys(TraceKeys{TraceID: "trace", SpanID: "span", Flags: "flags"})
+		defer SetTraceKeys(defaultTraceKeys())
+		entry := *Trace(tc)
+		entry.Data = entry.Fields()
+		var got map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(NewGCPFormatter().Format(entry)), &got))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got["logging.googleapis.com/trace"])
+		assert.Equal(t, "00f067aa0ba902b7", got["logging.googleapis.com/spanId"])
+		assert.Equal(t, true, got["logging.googleapis.com/trace_sampled"])
+		assert.NoError(t, json.Unmarshal([]byte(NewECSFormatter().Format(entry)), &got))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got["trace.id"])
+		assert.Equal(t, "00f067aa0ba902b7", got["span.id"])
+		record := otlpRecord(entry, entry.Fields(), entry.Raised)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record.TraceID)
+		assert.Equal(t, 1, record.Flags)
+	})
+	t.Run("must adds trace context of context", func(t *testing.T) {
+		Context(ContextWithTrace(context.Background(), tc)).Info("text message")
+		assert.Contains(t, testOutput.String(), "\"trace_id\":\"4bf92f3577b34da6a3ce929d0e0e4736\"")
+		testOutput.Reset()
+	})
+}
@@ -0,0 +1,215 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const webhookSlackTemplate = `{"text":{{json (lines .)}}}`
+
+// WebhookOption type of webhook sink option
+type WebhookOption func(*WebhookSink)
+
+// WebhookTemplate sets text/template of request body which is executed with batch of entries (default: slack payload),
+// template functions of template formatter and lines function which formats entries as single lines are available
+func WebhookTemplate(text, contentType string) WebhookOption {
+	return func(s *WebhookSink) {
+		s.template = text
+		s.contentType = contentType
+	}
+}
+
+// WebhookHeaders sets headers of requests
+func WebhookHeaders(headers map[string]string) WebhookOption {
+	return func(s *WebhookSink) {
+		s.headers = headers
+	}
+}
+
+// WebhookLevel sets minimum level of sent entries (default: LevelDebug)
+func WebhookLevel(lvl Level) WebhookOption {
+	return func(s *WebhookSink) {
+		s.level = lvl
+	}
+}
+
+// WebhookFilter sets filter of sent entries, entries which filter returns false for are not sent
+func WebhookFilter(filter func(Entry) bool) WebhookOption {
+	return func(s *WebhookSink) {
+		s.filter = filter
+	}
+}
+
+// WebhookBatch sets maximum size and time window of batches (default: 20, 10s)
+func WebhookBatch(size int, window time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.size = size
+		s.window = window
+	}
+}
+
+// WebhookBuffer sets capacity of queued entries, entries are dropped when queue is full (default: 1000)
+func WebhookBuffer(capacity int) WebhookOption {
+	return func(s *WebhookSink) {
+		s.capacity = capacity
+	}
+}
+
+// WebhookRateLimit limits requests to count per period, requests are spaced evenly and batches wait for their turn
+func WebhookRateLimit(count int, period time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		if count > 0 {
+			s.interval = period / time.Duration(count)
+		}
+	}
+}
+
+// WebhookRetry sets retries of failed requests and their backoff delays (default: 3, 1s, 30s)
+func WebhookRetry(retries int, min, max time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.retry = httpRetry{retries: retries, min: min, max: max}
+	}
+}
+
+// WebhookClient sets http client of requests (default: client with 10s timeout)
+func WebhookClient(client *http.Client) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client = client
+	}
+}
+
+// WebhookSink implements sink of http webhooks such as chat and incident tools,
+// entries are batched over time window and rendered with template into request body
+type WebhookSink struct {
+	url         string
+	template    string
+	contentType string
+	headers     map[string]string
+	level       Level
+	filter      func(Entry) bool
+	size        int
+	window      time.Duration
+	capacity    int
+	interval    time.Duration
+	retry       httpRetry
+	client      *http.Client
+
+	tmpl    *template.Template
+	next    time.Time
+	batcher *batcher
+}
+
+// NewWebhookSink returns new webhook sink which posts on url, template is validated by executing with sample entry
+func NewWebhookSink(url string, options ...WebhookOption) (*WebhookSink, error) {
+	s := &WebhookSink{
+		url:         url,
+		template:    webhookSlackTemplate,
+		contentType: "application/json",
+		size:        20,
+		window:      10 * time.Second,
+		capacity:    1000,
+		retry:       httpRetry{retries: 3, min: time.Second, max: 30 * time.Second},
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	funcs := templateFuncs(newTextFormatter(TextColors(ColorNever)))
+	lines := NewTextFormatter(TextColors(ColorNever), TextSingleLine())
+	funcs["lines"] = func(entries []Entry) string {
+		formatted := make([]string, len(entries))
+		for i, entry := range entries {
+			formatted[i] = lines.Format(entry)
+		}
+		return strings.Join(formatted, "\n")
+	}
+	tmpl, err := template.New("webhook").Funcs(funcs).Parse(s.template)
+	if err != nil {
+		return nil, err
+	}
+	s.tmpl = tmpl
+	sample := []Entry{{
+		Raised:  time.Now(),
+		Level:   LevelError,
+		Source:  "at main.main in main.go:1",
+		Message: "message",
+		Data:    map[string]interface{}{"key": "value"},
+	}}
+	if _, err := s.body(sample); err != nil {
+		return nil, err
+	}
+	s.batcher = newBatcher(s.size, s.window, s.capacity, s.flush)
+	return s, nil
+}
+
+// Send queues entry to send in next batch when it passes level and filter
+func (s *WebhookSink) Send(entry Entry) error {
+	if entry.Level < s.level || (s.filter != nil && !s.filter(entry)) {
+		return nil
+	}
+	return s.batcher.add(entry)
+}
+
+// Flush sends queued entries, it returns last error of failed entries since previous flush
+func (s *WebhookSink) Flush() error {
+	return s.batcher.sync()
+}
+
+// Close sends queued entries and stops sink, it returns last error of failed entries since previous flush
+func (s *WebhookSink) Close() error {
+	return s.batcher.close()
+}
+
+// body returns request body of entries, json content is validated
+func (s *WebhookSink) body(entries []Entry) ([]byte, error) {
+	var body bytes.Buffer
+	if err := s.tmpl.Execute(&body, entries); err != nil {
+		return nil, err
+	}
+	if strings.Contains(s.contentType, "json") && !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("log: webhook body is not valid json: %s", body.String())
+	}
+	return body.Bytes(), nil
+}
+
+func (s *WebhookSink) flush(entries []Entry) error {
+	body, err := s.body(entries)
+	if err == nil {
+		_, err = s.retry.do(s.client, func() (*http.Request, error) {
+			s.wait()
+			req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", s.contentType)
+			for key, value := range s.headers {
+				req.Header.Set(key, value)
+			}
+			return req, nil
+		})
+	}
+	if err != nil {
+		for _, entry := range entries {
+			sendFailed(entry, err)
+		}
+	}
+	return err
+}
+
+// wait waits for turn of request in rate limit, it is called before each attempt so retries are limited too
+func (s *WebhookSink) wait() {
+	if s.interval <= 0 {
+		return
+	}
+	turn := s.next
+	if now := time.Now(); turn.Before(now) {
+		turn = now
+	}
+	s.next = turn.Add(s.interval)
+	time.Sleep(time.Until(turn))
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testWebhookRequest struct {
+	contentType string
+	body        string
+	received    time.Time
+}
+
+// startTestWebhookServer receives requests, it responds with status of failures before accepting
+func startTestWebhookServer(t *testing.T, failures ...int) (*httptest.Server, <-chan testWebhookRequest) {
+	requests := make(chan testWebhookRequest, 10)
+	var calls uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if call := int(atomic.AddUint64(&calls, 1)); call <= len(failures) {
+			w.WriteHeader(failures[call-1])
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- testWebhookRequest{contentType: r.Header.Get("Content-Type"), body: string(body), received: time.Now()}
+	}))
+	return server, requests
+}
+
+func TestWebhookSink_Send(t *testing.T) {
+	resetTest()
+	entry := func(lvl Level, message string) Entry {
+		return Entry{
+			Raised:  time.Date(2020, 4, 10, 12, 30, 45, 0, time.UTC),
+			Level:   lvl,
+			Message: message,
+			Data:    map[string]interface{}{"key": "value"},
+		}
+	}
+	receive := func(requests <-chan testWebhookRequest) testWebhookRequest {
+		select {
+		case req := <-requests:
+			return req
+		case <-time.After(2 * time.Second):
+			t.Fatal("request is not received")
+			return testWebhookRequest{}
+		}
+	}
+	t.Run("must sends batch of filtered entries as slack payload", func(t *testing.T) {
+		server, requests := startTestWebhookServer(t)
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL, WebhookLevel(LevelError), WebhookBatch(2, time.Hour),
+			WebhookFilter(func(entry Entry) bool { return entry.Message != "ignored" }))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry(LevelInfo, "info message")))
+		assert.NoError(t, sink.Send(entry(LevelError, "ignored")))
+		assert.NoError(t, sink.Send(entry(LevelError, "first failure")))
+		assert.NoError(t, sink.Send(entry(LevelFatal, "second failure")))
+		req := receive(requests)
+		assert.Equal(t, "application/json", req.contentType)
+		var payload map[string]string
+		assert.NoError(t, json.Unmarshal([]byte(req.body), &payload))
+		lines := NewTextFormatter(TextColors(ColorNever), TextSingleLine())
+		assert.Equal(t, lines.Format(entry(LevelError, "first failure"))+"\n"+
+			lines.Format(entry(LevelFatal, "second failure")), payload["text"])
+	})
+	t.Run("must renders custom template", func(t *testing.T) {
+		server, requests := startTestWebhookServer(t)
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL,
+			WebhookTemplate(`{{range .}}{{upper .Level}}: {{.Message}};{{end}}`, "text/plain"))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry(LevelError, "failure")))
+		assert.NoError(t, sink.Flush())
+		req := receive(requests)
+		assert.Equal(t, "text/plain", req.contentType)
+		assert.Equal(t, "ERROR: failure;", req.body)
+	})
+	t.Run("must spaces requests in rate limit", func(t *testing.T) {
+		server, requests := startTestWebhookServer(t)
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL, WebhookBatch(1, time.Hour), WebhookRateLimit(2, 200*time.Millisecond))
+		assert.NoError(t, err)
+		defer sink.Close()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, sink.Send(entry(LevelError, "failure")))
+		}
+		first := receive(requests)
+		receive(requests)
+		last := receive(requests)
+		assert.True(t, last.received.Sub(first.received) >= 190*time.Millisecond)
+	})
+	t.Run("must spaces retries of requests in rate limit", func(t *testing.T) {
+		var attempts []time.Time
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts = append(attempts, time.Now())
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL, WebhookRateLimit(1, 100*time.Millisecond),
+			WebhookRetry(2, time.Millisecond, time.Millisecond))
+		assert.NoError(t, err)
+		assert.NoError(t, sink.Send(entry(LevelError, "failure")))
+		assert.Error(t, sink.Flush())
+		assert.Len(t, attempts, 3)
+		for i := 1; i < len(attempts); i++ {
+			assert.True(t, attempts[i].Sub(attempts[i-1]) >= 90*time.Millisecond)
+		}
+		assert.NoError(t, sink.Close())
+	})
+	t.Run("must retries failed requests", func(t *testing.T) {
+		server, requests := startTestWebhookServer(t, http.StatusTooManyRequests, http.StatusBadGateway)
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL, WebhookRetry(2, time.Millisecond, time.Millisecond))
+		assert.NoError(t, err)
+		defer sink.Close()
+		assert.NoError(t, sink.Send(entry(LevelError, "failure")))
+		assert.NoError(t, sink.Flush())
+		assert.Contains(t, receive(requests).body, "failure")
+	})
+	t.Run("must reports failed entries after retries", func(t *testing.T) {
+		server, _ := startTestWebhookServer(t, http.StatusBadRequest)
+		defer server.Close()
+		sink, err := NewWebhookSink(server.URL)
+		assert.NoError(t, err)
+		before := GetStats()
+		assert.NoError(t, sink.Send(entry(LevelError, "failure")))
+		assert.Error(t, sink.Close())
+		assert.Equal(t, before.FailedSends+1, GetStats().FailedSends)
+	})
+}
+
+func TestNewWebhookSink(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			name: "must returns error with invalid template",
+			text: `{{range .}`,
+		},
+		{
+			name: "must returns error when template fails",
+			text: `{{.Missing}}`,
+		},
+		{
+			name: "must returns error when json body is invalid",
+			text: `{"text": {{lines .}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhookSink("http://localhost", WebhookTemplate(tt.text, "application/json"))
+			assert.Error(t, err)
+		})
+	}
+}